@@ -0,0 +1,68 @@
+package api
+
+import (
+	"net/http"
+	entity "scheduler/application/entity"
+
+	"github.com/gin-gonic/gin"
+)
+
+// @Summary Worker pool status
+// @Description Shows the pool's bounds, queue depth and what each worker is doing
+// @Tags Admin
+// @Produce json
+// @Success 200 {object} entity.WorkerPoolStatus
+// @Router /admin/workers [get]
+func (s *Server) GetWorkers(c *gin.Context) {
+	c.JSON(http.StatusOK, s.Pool.Status())
+}
+
+// @Summary Resize the worker pool
+// @Description Sets the minimum and maximum number of workers. Workers are started or retired right away; busy workers above the new maximum finish their current task first
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Param bounds body entity.ResizePoolRequest true "Pool bounds"
+// @Success 200 {object} entity.WorkerPoolStatus
+// @Failure 400 {object} map[string]string
+// @Router /admin/workers [put]
+func (s *Server) ResizeWorkers(c *gin.Context) {
+	var req entity.ResizePoolRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request: " + err.Error()})
+		return
+	}
+
+	if err := s.Pool.Resize(req.Min, req.Max); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, s.Pool.Status())
+}
+
+// @Summary Circuit breakers
+// @Description Lists hosts with recent failures and the state of their circuit breaker
+// @Tags Admin
+// @Produce json
+// @Success 200 {array} entity.BreakerStatus
+// @Router /admin/breakers [get]
+func (s *Server) ListBreakers(c *gin.Context) {
+	c.JSON(http.StatusOK, s.Pool.Breakers())
+}
+
+// @Summary Reset a circuit breaker
+// @Description Closes the circuit for a host so requests to it are sent again
+// @Tags Admin
+// @Param host path string true "Host, with port if the URL has one"
+// @Success 200 {object} entity.BreakerStatus
+// @Failure 404 {object} map[string]string
+// @Router /admin/breakers/{host} [delete]
+func (s *Server) ResetBreaker(c *gin.Context) {
+	status, ok := s.Pool.ResetBreaker(c.Param("host"))
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "No breaker for host"})
+		return
+	}
+	c.JSON(http.StatusOK, status)
+}
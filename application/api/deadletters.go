@@ -0,0 +1,187 @@
+package api
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	entity "scheduler/application/entity"
+	"scheduler/database"
+	"scheduler/workers"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// @Summary List dead letters
+// @Description List runs that failed after exhausting their retries
+// @Tags DeadLetters
+// @Param page query int false "Page number"
+// @Param size query int false "Page size"
+// @Param status query string false "Dead letter status (pending, requeued)"
+// @Success 200 {object} entity.ListDeadLettersResponse
+// @Failure 500 {object} map[string]string
+// @Router /dead-letters [get]
+func (s *Server) ListDeadLetters(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	size, _ := strconv.Atoi(c.DefaultQuery("size", "10"))
+	status := c.Query("status")
+
+	offset := (page - 1) * size
+
+	deadLetters, err := s.DB.ListDeadLetters(c, database.ListDeadLettersParams{
+		Column1: status,
+		Limit:   int32(size),
+		Offset:  int32(offset),
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch dead letters"})
+		return
+	}
+
+	var responses []entity.DeadLetterResponse
+	for _, deadLetter := range deadLetters {
+		response, err := deadLetterToResponse(deadLetter)
+		if err != nil {
+			log.Printf("Error converting dead letter: %v", err)
+			continue
+		}
+		responses = append(responses, response)
+	}
+
+	c.JSON(http.StatusOK, entity.ListDeadLettersResponse{DeadLetters: responses})
+}
+
+// @Summary Get a dead letter
+// @Description Returns a dead letter with the request that was sent and the final error
+// @Tags DeadLetters
+// @Param id path string true "Dead letter ID"
+// @Success 200 {object} entity.DeadLetterResponse
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /dead-letters/{id} [get]
+func (s *Server) GetDeadLetter(c *gin.Context) {
+	idParam := c.Param("id")
+	var pguuid pgtype.UUID
+	err := pguuid.Scan(idParam)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid dead letter ID"})
+		return
+	}
+
+	deadLetter, err := s.DB.GetDeadLetter(c, pguuid)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Dead letter not found"})
+		return
+	}
+
+	response, err := deadLetterToResponse(deadLetter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to format response"})
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// @Summary Requeue a dead letter
+// @Description Sends the dead letter's target again, using the task's current config and the original idempotency key, in a run of its own, and marks the dead letter as requeued. Other targets are not sent, and the task's schedule, status and failure count are unchanged, so a paused task stays paused. A replay that fails creates a new dead letter.
+// @Tags DeadLetters
+// @Param id path string true "Dead letter ID"
+// @Success 202 {object} entity.RequeueDeadLetterResponse
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /dead-letters/{id}/requeue [post]
+func (s *Server) RequeueDeadLetter(c *gin.Context) {
+	idParam := c.Param("id")
+	var pguuid pgtype.UUID
+	err := pguuid.Scan(idParam)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid dead letter ID"})
+		return
+	}
+
+	deadLetter, err := s.DB.GetDeadLetter(c, pguuid)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Dead letter not found"})
+		return
+	}
+	if deadLetter.Status != "pending" {
+		c.JSON(http.StatusConflict, gin.H{"error": "Dead letter was already requeued"})
+		return
+	}
+
+	task, err := s.DB.GetTask(c, deadLetter.TaskID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Task not found"})
+		return
+	}
+	if task.Status == "cancelled" {
+		c.JSON(http.StatusConflict, gin.H{"error": "Task is cancelled"})
+		return
+	}
+
+	run, err := s.Pool.Replay(c, task, deadLetter)
+	if errors.Is(err, workers.ErrTargetRemoved) || errors.Is(err, workers.ErrShuttingDown) {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to replay dead letter"})
+		return
+	}
+
+	deadLetter, err = s.DB.MarkDeadLetterRequeued(c, pguuid)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update dead letter"})
+		return
+	}
+
+	response, err := deadLetterToResponse(deadLetter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to format response"})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, entity.RequeueDeadLetterResponse{
+		DeadLetter:  response,
+		ReplayRunID: run.ID,
+		TaskStatus:  task.Status,
+	})
+}
+
+// @Summary Discard a dead letter
+// @Description Deletes a dead letter without running its task again
+// @Tags DeadLetters
+// @Param id path string true "Dead letter ID"
+// @Success 200 {object} entity.DeadLetterResponse
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /dead-letters/{id} [delete]
+func (s *Server) DiscardDeadLetter(c *gin.Context) {
+	idParam := c.Param("id")
+	var pguuid pgtype.UUID
+	err := pguuid.Scan(idParam)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid dead letter ID"})
+		return
+	}
+
+	deadLetter, err := s.DB.DeleteDeadLetter(c, pguuid)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Dead letter not found"})
+		return
+	}
+
+	response, err := deadLetterToResponse(deadLetter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to format response"})
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
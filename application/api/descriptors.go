@@ -0,0 +1,99 @@
+package api
+
+import (
+	"io"
+	"net/http"
+	entity "scheduler/application/entity"
+	"scheduler/database"
+	"scheduler/workers"
+
+	"github.com/gin-gonic/gin"
+)
+
+const maxDescriptorSetBytes = 16 << 20
+
+// @Summary Upload a descriptor set
+// @Description Stores a serialized FileDescriptorSet (protoc --include_imports --descriptor_set_out) for grpc actions, replacing any set with the same name
+// @Tags DescriptorSets
+// @Accept application/octet-stream
+// @Produce json
+// @Param name path string true "Descriptor set name"
+// @Success 200 {object} entity.DescriptorSetResponse
+// @Failure 400 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /descriptor-sets/{name} [put]
+func (s *Server) UploadDescriptorSet(c *gin.Context) {
+	name := c.Param("name")
+
+	data, err := io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, maxDescriptorSetBytes))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to read descriptor set: " + err.Error()})
+		return
+	}
+
+	_, services, err := workers.ParseDescriptorSet(data)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if services == nil {
+		services = []string{}
+	}
+
+	set, err := s.DB.UpsertDescriptorSet(c, database.UpsertDescriptorSetParams{
+		Name:     name,
+		Data:     data,
+		Services: services,
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to store descriptor set"})
+		return
+	}
+
+	c.JSON(http.StatusOK, descriptorSetToResponse(set))
+}
+
+// @Summary List descriptor sets
+// @Description Lists uploaded descriptor sets and the services they define
+// @Tags DescriptorSets
+// @Success 200 {object} entity.ListDescriptorSetsResponse
+// @Failure 500 {object} map[string]string
+// @Router /descriptor-sets [get]
+func (s *Server) ListDescriptorSets(c *gin.Context) {
+	sets, err := s.DB.ListDescriptorSets(c)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch descriptor sets"})
+		return
+	}
+
+	responses := []entity.DescriptorSetResponse{}
+	for _, set := range sets {
+		responses = append(responses, entity.DescriptorSetResponse{
+			ID:        set.ID,
+			Name:      set.Name,
+			Services:  set.Services,
+			Size:      set.Size,
+			CreatedAt: set.CreatedAt.Time,
+			UpdatedAt: set.UpdatedAt.Time,
+		})
+	}
+
+	c.JSON(http.StatusOK, entity.ListDescriptorSetsResponse{DescriptorSets: responses})
+}
+
+// @Summary Delete a descriptor set
+// @Description Deletes a descriptor set. Tasks that still use it fail until it is uploaded again
+// @Tags DescriptorSets
+// @Param name path string true "Descriptor set name"
+// @Success 200 {object} entity.DescriptorSetResponse
+// @Failure 404 {object} map[string]string
+// @Router /descriptor-sets/{name} [delete]
+func (s *Server) DeleteDescriptorSet(c *gin.Context) {
+	set, err := s.DB.DeleteDescriptorSet(c, c.Param("name"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Descriptor set not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, descriptorSetToResponse(set))
+}
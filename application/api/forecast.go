@@ -0,0 +1,128 @@
+package api
+
+import (
+	"net/http"
+	entity "scheduler/application/entity"
+	"scheduler/scheduler"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// @Summary Forecast scheduled runs
+// @Description Simulates all active task triggers over a time range and returns a histogram of expected runs per bucket with peak concurrency estimates
+// @Tags Tasks
+// @Param from query string false "Range start (RFC3339), defaults to now"
+// @Param to query string false "Range end (RFC3339), defaults to one week after from"
+// @Param bucket query string false "Bucket size as a Go duration, defaults to 1m"
+// @Param workers query int false "Worker count to estimate against, defaults to the pool size"
+// @Param peaks query int false "Number of peak buckets to return, defaults to 10"
+// @Success 200 {object} entity.ForecastResponse
+// @Failure 400 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /forecast [get]
+func (s *Server) Forecast(c *gin.Context) {
+	from := s.Clock.Now()
+	if v := c.Query("from"); v != "" {
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid from time"})
+			return
+		}
+		from = t
+	}
+
+	to := from.Add(7 * 24 * time.Hour)
+	if v := c.Query("to"); v != "" {
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid to time"})
+			return
+		}
+		to = t
+	}
+
+	bucket, err := time.ParseDuration(c.DefaultQuery("bucket", "1m"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid bucket duration"})
+		return
+	}
+
+	workers := s.Pool.Size()
+	if v := c.Query("workers"); v != "" {
+		workers, err = strconv.Atoi(v)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid worker count"})
+			return
+		}
+	}
+	peaks, _ := strconv.Atoi(c.DefaultQuery("peaks", "10"))
+
+	tasks, err := s.DB.ListActiveTasks(c)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch tasks"})
+		return
+	}
+
+	averages, err := s.DB.ListAverageDurations(c)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch task durations"})
+		return
+	}
+	durations := make(map[pgtype.UUID]time.Duration, len(averages))
+	for _, avg := range averages {
+		durations[avg.TaskID] = time.Duration(avg.AvgDurationMs) * time.Millisecond
+	}
+
+	forecast, err := scheduler.Simulate(tasks, durations, scheduler.ForecastOptions{
+		From:    from,
+		To:      to,
+		Bucket:  bucket,
+		Workers: workers,
+		Peaks:   peaks,
+	})
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	response := entity.ForecastResponse{
+		From:            from,
+		To:              to,
+		Bucket:          bucket.String(),
+		Workers:         workers,
+		TotalRuns:       forecast.TotalRuns,
+		PeakConcurrency: forecast.PeakConcurrency,
+	}
+	for _, b := range forecast.Buckets {
+		response.Buckets = append(response.Buckets, forecastBucketToResponse(b, false))
+	}
+	for _, b := range forecast.Peaks {
+		response.Peaks = append(response.Peaks, forecastBucketToResponse(b, true))
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+func forecastBucketToResponse(bucket scheduler.ForecastBucket, withTasks bool) entity.ForecastBucketResponse {
+	response := entity.ForecastBucketResponse{
+		Start:           bucket.Start,
+		Runs:            bucket.Runs,
+		PeakConcurrency: bucket.PeakConcurrency,
+		Queued:          bucket.Queued,
+	}
+
+	if withTasks {
+		for _, t := range bucket.Tasks {
+			response.Tasks = append(response.Tasks, entity.ForecastTaskResponse{
+				ID:   t.TaskID,
+				Name: t.Name,
+				Runs: t.Runs,
+			})
+		}
+	}
+
+	return response
+}
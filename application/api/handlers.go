@@ -6,6 +6,8 @@ import (
 	"net/http"
 	entity "scheduler/application/entity"
 	"scheduler/database"
+	"scheduler/scheduler"
+	"scheduler/workers"
 	"strconv"
 	"time"
 
@@ -46,7 +48,7 @@ func (s *Server) GetTask(c *gin.Context) {
 }
 
 // @Summary Create a new task
-// @Description Create a task with one-off or cron trigger
+// @Description Create a task with a one-off, cron or rrule trigger
 // @Tags Tasks
 // @Accept json
 // @Produce json
@@ -64,32 +66,127 @@ func (s *Server) CreateTask(c *gin.Context) {
 		return
 	}
 
+	if err := s.Pool.Executors().Validate(req.Action); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid action: " + err.Error()})
+		return
+	}
+
+	var reqAuth []byte
+	if req.Action.Auth != nil {
+		if err := s.checkSecrets(c, workers.AuthSecrets(*req.Action.Auth)...); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid action: auth: " + err.Error()})
+			return
+		}
+		reqAuth, _ = json.Marshal(req.Action.Auth)
+	}
+
+	if err := validateFanOut(req.Action.SuccessRule, req.Action.Quorum, len(req.Action.Targets)); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
 	dateTime, err := StringToTimestamptz(req.Trigger.DateTime)
 	cron := StringToPgText(req.Trigger.Cron)
+	rrule := StringToPgText(req.Trigger.RRule)
+	timezone := StringToPgText(req.Trigger.Timezone)
 	reqHeaders, _ := json.Marshal(req.Action.Headers)
 	reqPayload, _ := json.Marshal(req.Action.Payload)
 
-	var nextRun pgtype.Timestamptz
+	var reqTargets []byte
+	if len(req.Action.Targets) > 0 {
+		reqTargets, _ = json.Marshal(req.Action.Targets)
+	}
+
+	actionType := req.Action.Type
+	if actionType == "" {
+		actionType = workers.DefaultActionType
+	}
+
+	var reqRetry []byte
+	if req.Retry != nil {
+		reqRetry, _ = json.Marshal(req.Retry)
+	}
+
+	var reqTimeouts []byte
+	if req.Timeouts != nil {
+		reqTimeouts, _ = json.Marshal(req.Timeouts)
+	}
+
+	var reqSuccess []byte
+	if req.Success != nil {
+		if err := workers.ValidateSuccessCriteria(actionType, *req.Success); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid success criteria: " + err.Error()})
+			return
+		}
+		reqSuccess, _ = json.Marshal(req.Success)
+	}
+
+	var reqCapture []byte
+	if req.Capture != nil {
+		reqCapture, _ = json.Marshal(req.Capture)
+	}
+
+	var reqSigning []byte
+	if req.Signing != nil {
+		if err := s.validateSigning(c, actionType, *req.Signing); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid signing config: " + err.Error()})
+			return
+		}
+		reqSigning, _ = json.Marshal(req.Signing)
+	}
 
-	if req.Trigger.Type == "one-off" && req.Trigger.DateTime != "" {
-		nextRun = dateTime
-	} else if req.Trigger.Type == "cron" && req.Trigger.Cron != "" {
-		if t, err := NextCronTime(req.Trigger.Cron); err == nil {
-			nextRun = pgtype.Timestamptz{Time: *t, Valid: true}
+	var reqTLS []byte
+	if req.TLS != nil {
+		if err := s.validateTLS(c, actionType, *req.TLS); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid tls config: " + err.Error()})
+			return
 		}
+		reqTLS, _ = json.Marshal(req.TLS)
+	}
+
+	mode := req.Action.Mode
+	if mode == "" {
+		mode = "parallel"
+	}
+	successRule := req.Action.SuccessRule
+	if successRule == "" {
+		successRule = "all"
+	}
+
+	nextRun, err := triggerNextRun(req.Trigger, s.Clock.Now())
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
 	}
 
 	task, err := s.DB.CreateTask(c, database.CreateTaskParams{
-		Name:            req.Name,
-		TriggerType:     req.Trigger.Type,
-		TriggerDatetime: dateTime,
-		TriggerCron:     cron,
-		ActionMethod:    req.Action.Method,
-		ActionUrl:       req.Action.URL,
-		ActionHeaders:   reqHeaders,
-		ActionPayload:   reqPayload,
-		Status:          "scheduled",
-		NextRun:         nextRun,
+		Name:               req.Name,
+		TriggerType:        req.Trigger.Type,
+		TriggerDatetime:    dateTime,
+		TriggerCron:        cron,
+		TriggerRrule:       rrule,
+		TriggerTimezone:    timezone,
+		RetryPolicy:        reqRetry,
+		Timeouts:           reqTimeouts,
+		SuccessCriteria:    reqSuccess,
+		Capture:            reqCapture,
+		Signing:            reqSigning,
+		Tls:                reqTLS,
+		PauseAfterFailures: int32(req.PauseAfterFailures),
+		ActionType:         actionType,
+		ActionMethod:       req.Action.Method,
+		ActionUrl:          req.Action.URL,
+		ActionConfig:       req.Action.Config,
+		ActionAuth:         reqAuth,
+		ActionProxy:        pgtype.Text{String: req.Action.Proxy, Valid: req.Action.Proxy != ""},
+		ActionHeaders:      reqHeaders,
+		ActionPayload:      reqPayload,
+		ActionTargets:      reqTargets,
+		ActionMode:         mode,
+		ActionSuccessRule:  successRule,
+		ActionQuorum:       int32(req.Action.Quorum),
+		Status:             "scheduled",
+		NextRun:            nextRun,
 	})
 
 	if err != nil {
@@ -105,12 +202,28 @@ func (s *Server) CreateTask(c *gin.Context) {
 			Type: task.TriggerType,
 		},
 		Action: entity.ActionData{
-			Method: task.ActionMethod,
-			URL:    task.ActionUrl,
+			Type:        task.ActionType,
+			Method:      task.ActionMethod,
+			URL:         task.ActionUrl,
+			Config:      req.Action.Config,
+			Auth:        req.Action.Auth,
+			Proxy:       req.Action.Proxy,
+			Targets:     req.Action.Targets,
+			Mode:        task.ActionMode,
+			SuccessRule: task.ActionSuccessRule,
+			Quorum:      int(task.ActionQuorum),
 		},
-		CreatedAt: task.CreatedAt.Time,
-		UpdatedAt: task.UpdatedAt.Time,
-		NextRun:   &task.NextRun.Time,
+		Retry:               req.Retry,
+		Timeouts:            req.Timeouts,
+		Success:             req.Success,
+		Capture:             req.Capture,
+		Signing:             req.Signing,
+		TLS:                 req.TLS,
+		PauseAfterFailures:  task.PauseAfterFailures,
+		ConsecutiveFailures: task.ConsecutiveFailures,
+		CreatedAt:           task.CreatedAt.Time,
+		UpdatedAt:           task.UpdatedAt.Time,
+		NextRun:             &task.NextRun.Time,
 	}
 
 	if task.TriggerDatetime.Valid {
@@ -119,6 +232,10 @@ func (s *Server) CreateTask(c *gin.Context) {
 	if task.TriggerCron.Valid {
 		response.Trigger.Cron = task.TriggerCron.String
 	}
+	if task.TriggerRrule.Valid {
+		response.Trigger.RRule = task.TriggerRrule.String
+		response.Trigger.Timezone = task.TriggerTimezone.String
+	}
 
 	var headers map[string]string
 	if err := json.Unmarshal(task.ActionHeaders, &headers); err == nil {
@@ -279,65 +396,99 @@ func (s *Server) UpdateTask(c *gin.Context) {
 	}
 
 	params := database.UpdateTaskParams{
-		ID:              pguuid,
-		Name:            currTask.Name,
-		TriggerType:     currTask.TriggerType,
-		TriggerDatetime: currTask.TriggerDatetime,
-		TriggerCron:     currTask.TriggerCron,
-		ActionMethod:    currTask.ActionMethod,
-		ActionUrl:       currTask.ActionUrl,
-		ActionHeaders:   currTask.ActionHeaders,
-		ActionPayload:   currTask.ActionPayload,
-		Status:          currTask.Status,
-		NextRun:         currTask.NextRun,
+		ID:                 pguuid,
+		Name:               currTask.Name,
+		TriggerType:        currTask.TriggerType,
+		TriggerDatetime:    currTask.TriggerDatetime,
+		TriggerCron:        currTask.TriggerCron,
+		TriggerRrule:       currTask.TriggerRrule,
+		TriggerTimezone:    currTask.TriggerTimezone,
+		RetryPolicy:        currTask.RetryPolicy,
+		Timeouts:           currTask.Timeouts,
+		SuccessCriteria:    currTask.SuccessCriteria,
+		Capture:            currTask.Capture,
+		Signing:            currTask.Signing,
+		Tls:                currTask.Tls,
+		PauseAfterFailures: currTask.PauseAfterFailures,
+		ActionType:         currTask.ActionType,
+		ActionMethod:       currTask.ActionMethod,
+		ActionUrl:          currTask.ActionUrl,
+		ActionConfig:       currTask.ActionConfig,
+		ActionAuth:         currTask.ActionAuth,
+		ActionProxy:        currTask.ActionProxy,
+		ActionHeaders:      currTask.ActionHeaders,
+		ActionPayload:      currTask.ActionPayload,
+		Status:             currTask.Status,
+		NextRun:            currTask.NextRun,
+		ActionTargets:      currTask.ActionTargets,
+		ActionMode:         currTask.ActionMode,
+		ActionSuccessRule:  currTask.ActionSuccessRule,
+		ActionQuorum:       currTask.ActionQuorum,
 	}
 
 	if req.Name != nil {
 		params.Name = *req.Name
 	}
 
+	if req.PauseAfterFailures != nil {
+		params.PauseAfterFailures = int32(*req.PauseAfterFailures)
+	}
+
 	if req.Trigger != nil {
 		params.TriggerType = req.Trigger.Type
 
-		if req.Trigger.Type == "one-off" {
-			if req.Trigger.DateTime == "" {
-				c.JSON(http.StatusBadRequest, gin.H{"error": "datetime is required for one-off tasks"})
-				return
-			}
-
-			dateTime, err := StringToTimestamptz(req.Trigger.DateTime)
-			if err != nil {
-				c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid datetime format"})
-				return
-			}
+		nextRun, err := triggerNextRun(*req.Trigger, s.Clock.Now())
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		params.NextRun = nextRun
 
-			params.TriggerDatetime = dateTime
-			params.NextRun = dateTime
+		if req.Trigger.Type == "one-off" {
+			params.TriggerDatetime = nextRun
 			params.TriggerCron = pgtype.Text{Valid: false}
 		} else if req.Trigger.Type == "cron" {
-			if req.Trigger.Cron == "" {
-				c.JSON(http.StatusBadRequest, gin.H{"error": "cron expression is required for cron tasks"})
-				return
-			}
-
-			nextTime, err := NextCronTime(req.Trigger.Cron)
-			if err != nil {
-				c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid cron expression: " + err.Error()})
-				return
-			}
-
 			params.TriggerCron = pgtype.Text{String: req.Trigger.Cron, Valid: true}
 			params.TriggerDatetime = pgtype.Timestamptz{Valid: false}
-			params.NextRun = pgtype.Timestamptz{Time: *nextTime, Valid: true}
+		} else if req.Trigger.Type == "rrule" {
+			params.TriggerRrule = pgtype.Text{String: req.Trigger.RRule, Valid: true}
+			params.TriggerTimezone = pgtype.Text{String: req.Trigger.Timezone, Valid: req.Trigger.Timezone != ""}
+			params.TriggerCron = pgtype.Text{Valid: false}
+			params.TriggerDatetime = pgtype.Timestamptz{Valid: false}
 		}
 
 	}
 
 	if req.Action != nil {
+		if req.Action.Type != "" {
+			params.ActionType = req.Action.Type
+		}
+
+		if req.Action.Config != nil {
+			params.ActionConfig = req.Action.Config
+		}
+
+		if req.Action.Auth != nil {
+			if err := s.checkSecrets(c, workers.AuthSecrets(*req.Action.Auth)...); err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid action: auth: " + err.Error()})
+				return
+			}
+			authJSON, err := json.Marshal(req.Action.Auth)
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to process auth config"})
+				return
+			}
+			params.ActionAuth = authJSON
+		}
+
 		if req.Action.Method != "" {
 			params.ActionMethod = req.Action.Method
 		}
 
+		if req.Action.Proxy != "" {
+			params.ActionProxy = pgtype.Text{String: req.Action.Proxy, Valid: true}
+		}
+
 		if req.Action.URL != "" {
 			params.ActionUrl = req.Action.URL
 		}
@@ -359,6 +510,152 @@ func (s *Server) UpdateTask(c *gin.Context) {
 			}
 			params.ActionPayload = payloadJSON
 		}
+
+		if req.Action.Targets != nil {
+			targetsJSON, err := json.Marshal(req.Action.Targets)
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to process targets"})
+				return
+			}
+			params.ActionTargets = targetsJSON
+		}
+
+		if req.Action.Mode != "" {
+			params.ActionMode = req.Action.Mode
+		}
+
+		if req.Action.SuccessRule != "" {
+			params.ActionSuccessRule = req.Action.SuccessRule
+			params.ActionQuorum = int32(req.Action.Quorum)
+		}
+
+		var targets []entity.TargetData
+		json.Unmarshal(params.ActionTargets, &targets)
+		var headers map[string]string
+		json.Unmarshal(params.ActionHeaders, &headers)
+		var payload interface{}
+		json.Unmarshal(params.ActionPayload, &payload)
+		var auth *entity.AuthConfig
+		if params.ActionAuth != nil && string(params.ActionAuth) != "null" {
+			json.Unmarshal(params.ActionAuth, &auth)
+		}
+
+		action := entity.ActionData{
+			Type:    params.ActionType,
+			Method:  params.ActionMethod,
+			URL:     params.ActionUrl,
+			Headers: headers,
+			Payload: payload,
+			Config:  params.ActionConfig,
+			Auth:    auth,
+			Proxy:   params.ActionProxy.String,
+			Targets: targets,
+		}
+		if err := s.Pool.Executors().Validate(action); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid action: " + err.Error()})
+			return
+		}
+
+		if err := validateFanOut(params.ActionSuccessRule, int(params.ActionQuorum), len(targets)); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	if req.Retry != nil {
+		retryJSON, err := json.Marshal(req.Retry)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to process retry policy"})
+			return
+		}
+		params.RetryPolicy = retryJSON
+	}
+
+	if req.Timeouts != nil {
+		timeoutsJSON, err := json.Marshal(req.Timeouts)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to process timeouts"})
+			return
+		}
+		params.Timeouts = timeoutsJSON
+	}
+
+	if req.Success != nil {
+		successJSON, err := json.Marshal(req.Success)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to process success criteria"})
+			return
+		}
+		params.SuccessCriteria = successJSON
+	}
+
+	// Status codes depend on the action type, so a new type is checked
+	// against the existing criteria too.
+	if req.Success != nil || req.Action != nil {
+		var criteria *entity.SuccessCriteria
+		if params.SuccessCriteria != nil && string(params.SuccessCriteria) != "null" {
+			json.Unmarshal(params.SuccessCriteria, &criteria)
+		}
+		if criteria != nil {
+			if err := workers.ValidateSuccessCriteria(params.ActionType, *criteria); err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid success criteria: " + err.Error()})
+				return
+			}
+		}
+	}
+
+	if req.Capture != nil {
+		captureJSON, err := json.Marshal(req.Capture)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to process capture config"})
+			return
+		}
+		params.Capture = captureJSON
+	}
+
+	if req.Signing != nil {
+		signingJSON, err := json.Marshal(req.Signing)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to process signing config"})
+			return
+		}
+		params.Signing = signingJSON
+	}
+
+	// A new action type can invalidate the existing signing config too.
+	if req.Signing != nil || req.Action != nil {
+		var signing *entity.SigningConfig
+		if params.Signing != nil && string(params.Signing) != "null" {
+			json.Unmarshal(params.Signing, &signing)
+		}
+		if signing != nil {
+			if err := s.validateSigning(c, params.ActionType, *signing); err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid signing config: " + err.Error()})
+				return
+			}
+		}
+	}
+
+	if req.TLS != nil {
+		tlsJSON, err := json.Marshal(req.TLS)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to process tls config"})
+			return
+		}
+		params.Tls = tlsJSON
+	}
+
+	if req.TLS != nil || req.Action != nil {
+		var tlsConfig *entity.TLSConfig
+		if params.Tls != nil && string(params.Tls) != "null" {
+			json.Unmarshal(params.Tls, &tlsConfig)
+		}
+		if tlsConfig != nil {
+			if err := s.validateTLS(c, params.ActionType, *tlsConfig); err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid tls config: " + err.Error()})
+				return
+			}
+		}
 	}
 
 	updatedTask, err := s.DB.UpdateTask(c, params)
@@ -401,3 +698,106 @@ func (s *Server) ListAllTasksResults(c *gin.Context) {
 
 	c.JSON(http.StatusOK, gin.H{"results": response})
 }
+
+// @Summary List runs of a task
+// @Description Get runs of a specific task with their per-target results
+// @Tags TaskResults
+// @Param id path string true "Task ID"
+// @Success 200 {object} map[string][]entity.TaskRunResponse
+// @Failure 400 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /tasks/{id}/runs [get]
+func (s *Server) ListTaskRuns(c *gin.Context) {
+	idParam := c.Param("id")
+	var pguuid pgtype.UUID
+	err := pguuid.Scan(idParam)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid task ID"})
+		return
+	}
+
+	runs, err := s.DB.ListTaskRuns(c, pguuid)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch task runs"})
+		return
+	}
+
+	results, err := s.DB.ListTaskResults(c, pguuid)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch task results"})
+		return
+	}
+
+	resultsByRun := make(map[pgtype.UUID][]entity.TaskResultResponse)
+	for _, result := range results {
+		if !result.RunID.Valid {
+			continue
+		}
+		resultResponse, err := taskResultToResponse(result)
+		if err != nil {
+			log.Printf("Error converting result: %v", err)
+			continue
+		}
+		resultsByRun[result.RunID] = append(resultsByRun[result.RunID], resultResponse)
+	}
+
+	var response []entity.TaskRunResponse
+	for _, run := range runs {
+		response = append(response, taskRunToResponse(run, resultsByRun[run.ID]))
+	}
+
+	c.JSON(http.StatusOK, gin.H{"runs": response})
+}
+
+// @Summary Resume a paused task
+// @Description Resume a task that was paused after consecutive failed runs
+// @Tags Tasks
+// @Param id path string true "Task ID"
+// @Success 200 {object} entity.TaskResponse
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /tasks/{id}/resume [post]
+func (s *Server) ResumeTask(c *gin.Context) {
+	idParam := c.Param("id")
+	var pguuid pgtype.UUID
+	err := pguuid.Scan(idParam)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid task ID"})
+		return
+	}
+
+	task, err := s.DB.GetTask(c, pguuid)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Task not found"})
+		return
+	}
+	if task.Status != "paused" {
+		c.JSON(http.StatusConflict, gin.H{"error": "Task is not paused"})
+		return
+	}
+
+	next, ok, err := scheduler.TriggerFromTask(task).Next(s.Clock.Now())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to compute next run: " + err.Error()})
+		return
+	}
+
+	task, err = s.DB.RescheduleTask(c, database.RescheduleTaskParams{
+		ID:      pguuid,
+		NextRun: pgtype.Timestamptz{Time: next, Valid: ok},
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to resume task"})
+		return
+	}
+
+	response, err := taskToResponse(task)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to format response"})
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
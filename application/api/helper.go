@@ -1,14 +1,18 @@
 package api
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"log"
 	entity "scheduler/application/entity"
 	"scheduler/database"
+	"scheduler/scheduler"
+	"scheduler/workers"
 	"time"
 
 	"github.com/jackc/pgx/v5/pgtype"
-	"github.com/robfig/cron/v3"
 )
 
 func taskToResponse(task database.Task) (entity.TaskResponse, error) {
@@ -17,6 +21,8 @@ func taskToResponse(task database.Task) (entity.TaskResponse, error) {
 		Type:     task.TriggerType,
 		DateTime: task.TriggerDatetime.Time.Format(time.RFC3339),
 		Cron:     task.TriggerCron.String,
+		RRule:    task.TriggerRrule.String,
+		Timezone: task.TriggerTimezone.String,
 	}
 	var headers map[string]string
 
@@ -26,25 +32,164 @@ func taskToResponse(task database.Task) (entity.TaskResponse, error) {
 		return entity.TaskResponse{}, err
 	}
 
+	var targets []entity.TargetData
+	if task.ActionTargets != nil {
+		if err := json.Unmarshal(task.ActionTargets, &targets); err != nil {
+			log.Printf("failed to unmarshal targets: %v", err)
+			return entity.TaskResponse{}, err
+		}
+	}
+
 	action := entity.ActionData{
-		Method:  task.ActionMethod,
-		URL:     task.ActionUrl,
-		Headers: headers,
-		Payload: task.ActionPayload,
+		Type:        task.ActionType,
+		Method:      task.ActionMethod,
+		URL:         task.ActionUrl,
+		Headers:     headers,
+		Payload:     task.ActionPayload,
+		Targets:     targets,
+		Mode:        task.ActionMode,
+		SuccessRule: task.ActionSuccessRule,
+		Quorum:      int(task.ActionQuorum),
+		Proxy:       task.ActionProxy.String,
+	}
+	if task.ActionConfig != nil && string(task.ActionConfig) != "null" {
+		action.Config = task.ActionConfig
+	}
+	if task.ActionAuth != nil && string(task.ActionAuth) != "null" {
+		action.Auth = &entity.AuthConfig{}
+		if err := json.Unmarshal(task.ActionAuth, action.Auth); err != nil {
+			log.Printf("failed to unmarshal auth config: %v", err)
+			return entity.TaskResponse{}, err
+		}
+	}
+
+	var retry *entity.RetryPolicy
+	if task.RetryPolicy != nil && string(task.RetryPolicy) != "null" {
+		retry = &entity.RetryPolicy{}
+		if err := json.Unmarshal(task.RetryPolicy, retry); err != nil {
+			log.Printf("failed to unmarshal retry policy: %v", err)
+			return entity.TaskResponse{}, err
+		}
+	}
+
+	var timeouts *entity.TimeoutConfig
+	if task.Timeouts != nil && string(task.Timeouts) != "null" {
+		timeouts = &entity.TimeoutConfig{}
+		if err := json.Unmarshal(task.Timeouts, timeouts); err != nil {
+			log.Printf("failed to unmarshal timeouts: %v", err)
+			return entity.TaskResponse{}, err
+		}
+	}
+
+	var success *entity.SuccessCriteria
+	if task.SuccessCriteria != nil && string(task.SuccessCriteria) != "null" {
+		success = &entity.SuccessCriteria{}
+		if err := json.Unmarshal(task.SuccessCriteria, success); err != nil {
+			log.Printf("failed to unmarshal success criteria: %v", err)
+			return entity.TaskResponse{}, err
+		}
+	}
+
+	var capture *entity.CaptureConfig
+	if task.Capture != nil && string(task.Capture) != "null" {
+		capture = &entity.CaptureConfig{}
+		if err := json.Unmarshal(task.Capture, capture); err != nil {
+			log.Printf("failed to unmarshal capture config: %v", err)
+			return entity.TaskResponse{}, err
+		}
+	}
+
+	var signing *entity.SigningConfig
+	if task.Signing != nil && string(task.Signing) != "null" {
+		signing = &entity.SigningConfig{}
+		if err := json.Unmarshal(task.Signing, signing); err != nil {
+			log.Printf("failed to unmarshal signing config: %v", err)
+			return entity.TaskResponse{}, err
+		}
+	}
+
+	var tlsConfig *entity.TLSConfig
+	if task.Tls != nil && string(task.Tls) != "null" {
+		tlsConfig = &entity.TLSConfig{}
+		if err := json.Unmarshal(task.Tls, tlsConfig); err != nil {
+			log.Printf("failed to unmarshal tls config: %v", err)
+			return entity.TaskResponse{}, err
+		}
 	}
 
 	return entity.TaskResponse{
-		ID:        task.ID,
-		Name:      task.Name,
-		Trigger:   trigger,
-		Action:    action,
-		Status:    task.Status,
-		CreatedAt: task.CreatedAt.Time,
-		UpdatedAt: task.UpdatedAt.Time,
-		NextRun:   &task.NextRun.Time,
+		ID:                  task.ID,
+		Name:                task.Name,
+		Trigger:             trigger,
+		Action:              action,
+		Retry:               retry,
+		Timeouts:            timeouts,
+		Success:             success,
+		Capture:             capture,
+		Signing:             signing,
+		TLS:                 tlsConfig,
+		PauseAfterFailures:  task.PauseAfterFailures,
+		ConsecutiveFailures: task.ConsecutiveFailures,
+		Status:              task.Status,
+		CreatedAt:           task.CreatedAt.Time,
+		UpdatedAt:           task.UpdatedAt.Time,
+		NextRun:             &task.NextRun.Time,
 	}, nil
 }
 
+// validateSigning checks a signing config and that the secret it names
+// exists.
+func (s *Server) validateSigning(ctx context.Context, actionType string, cfg entity.SigningConfig) error {
+	if err := workers.ValidateSigning(actionType, cfg); err != nil {
+		return err
+	}
+	return s.checkSecrets(ctx, cfg.Secret)
+}
+
+// validateTLS checks a task's tls config, the profile it names and the
+// secret holding its client key.
+func (s *Server) validateTLS(ctx context.Context, actionType string, cfg entity.TLSConfig) error {
+	if err := workers.ValidateTLS(actionType, cfg); err != nil {
+		return err
+	}
+	if cfg.Profile != "" {
+		if _, err := s.DB.GetTLSProfile(ctx, cfg.Profile); err != nil {
+			return fmt.Errorf("tls profile %q not found", cfg.Profile)
+		}
+	}
+	if cfg.ClientKeyRef != "" {
+		return s.checkSecrets(ctx, cfg.ClientKeyRef)
+	}
+	return nil
+}
+
+// checkSecrets makes sure the secrets a task references exist, so a typo
+// doesn't only show up when the task runs.
+func (s *Server) checkSecrets(ctx context.Context, names ...string) error {
+	if s.Pool.Secrets() == nil {
+		return errors.New("secrets are not configured")
+	}
+	for _, name := range names {
+		if _, err := s.DB.GetSecret(ctx, name); err != nil {
+			return fmt.Errorf("secret %q not found", name)
+		}
+	}
+	return nil
+}
+
+func validateFanOut(successRule string, quorum int, targets int) error {
+	if successRule != "quorum" {
+		return nil
+	}
+	if targets == 0 {
+		targets = 1
+	}
+	if quorum < 1 || quorum > targets {
+		return fmt.Errorf("quorum must be between 1 and %d", targets)
+	}
+	return nil
+}
+
 func StringToTimestamptz(s string) (pgtype.Timestamptz, error) {
 	parsedTime, err := time.Parse(time.RFC3339, s)
 	if err != nil {
@@ -60,25 +205,77 @@ func StringToPgText(s string) pgtype.Text {
 	}
 }
 
-func NextCronTime(expr string) (*time.Time, error) {
-	schedule, err := cron.ParseStandard(expr)
+// triggerNextRun validates a trigger and returns when it first fires after
+// now. Every trigger type is checked by computing that time, so expressions
+// the scheduler can't evaluate are rejected up front.
+func triggerNextRun(trigger entity.TriggerData, now time.Time) (pgtype.Timestamptz, error) {
+	switch trigger.Type {
+	case "one-off":
+		if trigger.DateTime == "" {
+			return pgtype.Timestamptz{}, errors.New("datetime is required for one-off tasks")
+		}
+		dateTime, err := StringToTimestamptz(trigger.DateTime)
+		if err != nil {
+			return pgtype.Timestamptz{}, errors.New("invalid datetime format")
+		}
+		return dateTime, nil
+
+	case "cron":
+		if trigger.Cron == "" {
+			return pgtype.Timestamptz{}, errors.New("cron expression is required for cron tasks")
+		}
+		next, err := NextCronTime(trigger.Cron, now)
+		if err != nil {
+			return pgtype.Timestamptz{}, fmt.Errorf("invalid cron expression: %w", err)
+		}
+		return pgtype.Timestamptz{Time: *next, Valid: true}, nil
+
+	case "rrule":
+		if trigger.RRule == "" {
+			return pgtype.Timestamptz{}, errors.New("rrule is required for rrule tasks")
+		}
+		next, err := NextRRuleTime(trigger.RRule, trigger.Timezone, now)
+		if err != nil {
+			return pgtype.Timestamptz{}, fmt.Errorf("invalid rrule: %w", err)
+		}
+		return pgtype.Timestamptz{Time: *next, Valid: true}, nil
+	}
+	return pgtype.Timestamptz{}, fmt.Errorf("unknown trigger type %q", trigger.Type)
+}
+
+func NextCronTime(expr string, now time.Time) (*time.Time, error) {
+	next, _, err := scheduler.Trigger{Type: "cron", Cron: expr}.Next(now)
+	if err != nil {
+		return nil, err
+	}
+	return &next, nil
+}
+
+func NextRRuleTime(expr string, timezone string, now time.Time) (*time.Time, error) {
+	next, ok, err := scheduler.Trigger{Type: "rrule", RRule: expr, Timezone: timezone}.Next(now)
 	if err != nil {
 		return nil, err
 	}
-	now := time.Now().UTC()
-	next := schedule.Next(now)
+	if !ok {
+		return nil, errors.New("rrule has no upcoming occurrences")
+	}
 	return &next, nil
 }
 
 func taskResultToResponse(result database.TaskResult) (entity.TaskResultResponse, error) {
 	response := entity.TaskResultResponse{
-		ID:         result.ID,
-		TaskID:     result.TaskID,
-		RunAt:      result.RunAt.Time,
-		StatusCode: result.StatusCode,
-		Success:    result.Success,
-		DurationMs: result.DurationMs,
-		CreatedAt:  result.CreatedAt.Time,
+		ID:            result.ID,
+		TaskID:        result.TaskID,
+		RunID:         result.RunID,
+		TargetIndex:   result.TargetIndex,
+		TargetURL:     result.TargetUrl,
+		Attempt:       result.Attempt,
+		BodyTruncated: result.BodyTruncated,
+		RunAt:         result.RunAt.Time,
+		StatusCode:    result.StatusCode,
+		Success:       result.Success,
+		DurationMs:    result.DurationMs,
+		CreatedAt:     result.CreatedAt.Time,
 	}
 
 	if result.ResponseHeaders != nil {
@@ -94,9 +291,110 @@ func taskResultToResponse(result database.TaskResult) (entity.TaskResultResponse
 		}
 	}
 
+	if result.BodyEncoding.Valid {
+		response.BodyEncoding = result.BodyEncoding.String
+	}
+
 	if result.ErrorMessage.Valid {
 		response.ErrorMessage = result.ErrorMessage.String
 	}
+	if result.ErrorClass.Valid {
+		response.ErrorClass = result.ErrorClass.String
+	}
+	if result.IdempotencyKey.Valid {
+		response.IdempotencyKey = result.IdempotencyKey.String
+	}
+	if result.ExitCode.Valid {
+		response.ExitCode = &result.ExitCode.Int32
+	}
+	if result.Stderr.Valid {
+		response.Stderr = result.Stderr.String
+	}
 
 	return response, nil
 }
+
+func taskRunToResponse(run database.TaskRun, results []entity.TaskResultResponse) entity.TaskRunResponse {
+	response := entity.TaskRunResponse{
+		ID:               run.ID,
+		TaskID:           run.TaskID,
+		RunNumber:        run.RunNumber,
+		Status:           run.Status,
+		SuccessRule:      run.SuccessRule,
+		TargetsTotal:     run.TargetsTotal,
+		TargetsSucceeded: run.TargetsSucceeded,
+		StartedAt:        run.StartedAt.Time,
+		Results:          results,
+	}
+
+	if run.ScheduledAt.Valid {
+		response.ScheduledAt = &run.ScheduledAt.Time
+	}
+	if run.FinishedAt.Valid {
+		response.FinishedAt = &run.FinishedAt.Time
+	}
+
+	return response
+}
+
+func deadLetterToResponse(deadLetter database.DeadLetter) (entity.DeadLetterResponse, error) {
+	response := entity.DeadLetterResponse{
+		ID:          deadLetter.ID,
+		TaskID:      deadLetter.TaskID,
+		RunID:       deadLetter.RunID,
+		TargetIndex: deadLetter.TargetIndex,
+		StatusCode:  deadLetter.StatusCode,
+		Attempts:    deadLetter.Attempts,
+		Status:      deadLetter.Status,
+		CreatedAt:   deadLetter.CreatedAt.Time,
+		UpdatedAt:   deadLetter.UpdatedAt.Time,
+	}
+
+	if err := json.Unmarshal(deadLetter.Request, &response.Request); err != nil {
+		log.Printf("failed to unmarshal dead letter request: %v", err)
+		return entity.DeadLetterResponse{}, err
+	}
+
+	if deadLetter.ErrorMessage.Valid {
+		response.ErrorMessage = deadLetter.ErrorMessage.String
+	}
+	if deadLetter.ErrorClass.Valid {
+		response.ErrorClass = deadLetter.ErrorClass.String
+	}
+
+	return response, nil
+}
+
+func descriptorSetToResponse(set database.DescriptorSet) entity.DescriptorSetResponse {
+	return entity.DescriptorSetResponse{
+		ID:        set.ID,
+		Name:      set.Name,
+		Services:  set.Services,
+		Size:      int32(len(set.Data)),
+		CreatedAt: set.CreatedAt.Time,
+		UpdatedAt: set.UpdatedAt.Time,
+	}
+}
+
+func secretToResponse(secret database.Secret) entity.SecretResponse {
+	return entity.SecretResponse{
+		ID:        secret.ID,
+		Name:      secret.Name,
+		CreatedAt: secret.CreatedAt.Time,
+		UpdatedAt: secret.UpdatedAt.Time,
+	}
+}
+
+func tlsProfileToResponse(profile database.TlsProfile) (entity.TLSProfileResponse, error) {
+	var cfg entity.TLSConfig
+	if err := json.Unmarshal(profile.Config, &cfg); err != nil {
+		return entity.TLSProfileResponse{}, err
+	}
+	return entity.TLSProfileResponse{
+		ID:        profile.ID,
+		Name:      profile.Name,
+		Config:    cfg,
+		CreatedAt: profile.CreatedAt.Time,
+		UpdatedAt: profile.UpdatedAt.Time,
+	}, nil
+}
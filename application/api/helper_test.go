@@ -0,0 +1,74 @@
+package api
+
+import (
+	entity "scheduler/application/entity"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestValidateFanOut(t *testing.T) {
+	tests := []struct {
+		rule    string
+		quorum  int
+		targets int
+		ok      bool
+	}{
+		{"all", 0, 3, true},
+		{"any", 0, 3, true},
+		{"quorum", 2, 3, true},
+		{"quorum", 3, 3, true},
+		{"quorum", 4, 3, false},
+		{"quorum", 0, 3, false},
+		// A task without targets has its single URL.
+		{"quorum", 1, 0, true},
+		{"quorum", 2, 0, false},
+	}
+	for _, tt := range tests {
+		err := validateFanOut(tt.rule, tt.quorum, tt.targets)
+		if (err == nil) != tt.ok {
+			t.Errorf("validateFanOut(%q, %d, %d) = %v", tt.rule, tt.quorum, tt.targets, err)
+		}
+	}
+}
+
+func TestTriggerNextRun(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		trigger entity.TriggerData
+		want    time.Time
+		err     string
+	}{
+		{"one-off", entity.TriggerData{Type: "one-off", DateTime: "2026-04-01T09:00:00Z"}, time.Date(2026, 4, 1, 9, 0, 0, 0, time.UTC), ""},
+		{"one-off without datetime", entity.TriggerData{Type: "one-off"}, time.Time{}, "datetime is required"},
+		{"one-off bad datetime", entity.TriggerData{Type: "one-off", DateTime: "tomorrow"}, time.Time{}, "invalid datetime format"},
+		{"cron", entity.TriggerData{Type: "cron", Cron: "0 * * * *"}, time.Date(2026, 3, 1, 13, 0, 0, 0, time.UTC), ""},
+		{"cron without expression", entity.TriggerData{Type: "cron"}, time.Time{}, "cron expression is required"},
+		{"cron bad expression", entity.TriggerData{Type: "cron", Cron: "61 * * * *"}, time.Time{}, "invalid cron expression"},
+		{"cron wrong field count", entity.TriggerData{Type: "cron", Cron: "* * *"}, time.Time{}, "invalid cron expression"},
+		{"rrule", entity.TriggerData{Type: "rrule", RRule: "DTSTART:20260101T090000Z\nRRULE:FREQ=DAILY"}, time.Date(2026, 3, 2, 9, 0, 0, 0, time.UTC), ""},
+		{"rrule without rule", entity.TriggerData{Type: "rrule"}, time.Time{}, "rrule is required"},
+		{"rrule bad rule", entity.TriggerData{Type: "rrule", RRule: "DTSTART:20260101T090000Z\nRRULE:FREQ=SOMETIMES"}, time.Time{}, "invalid rrule"},
+		{"rrule in the past", entity.TriggerData{Type: "rrule", RRule: "DTSTART:20250101T090000Z\nRRULE:FREQ=DAILY;COUNT=2"}, time.Time{}, "no upcoming occurrences"},
+		{"unknown type", entity.TriggerData{Type: "interval"}, time.Time{}, "unknown trigger type"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := triggerNextRun(tt.trigger, now)
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Fatalf("triggerNextRun = %v, %v; want error containing %q", got, err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !got.Valid || !got.Time.Equal(tt.want) {
+				t.Errorf("triggerNextRun = %v, want %v", got.Time, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,104 @@
+package api
+
+import (
+	"net/http"
+	"regexp"
+	entity "scheduler/application/entity"
+	"scheduler/database"
+
+	"github.com/gin-gonic/gin"
+)
+
+var secretNamePattern = regexp.MustCompile(`^[A-Za-z0-9_.-]{1,128}$`)
+
+// @Summary Store a secret
+// @Description Encrypts and stores a secret under the name, replacing any existing value. Actions reference it as {{secret "name"}}; the value is never returned
+// @Tags Secrets
+// @Accept json
+// @Produce json
+// @Param name path string true "Secret name"
+// @Param secret body entity.SecretRequest true "Secret value"
+// @Success 200 {object} entity.SecretResponse
+// @Failure 400 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Failure 503 {object} map[string]string
+// @Router /secrets/{name} [put]
+func (s *Server) PutSecret(c *gin.Context) {
+	box := s.Pool.Secrets()
+	if box == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Secrets are not configured"})
+		return
+	}
+
+	name := c.Param("name")
+	if !secretNamePattern.MatchString(name) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Secret names may only contain letters, digits, '_', '.' and '-'"})
+		return
+	}
+
+	var req entity.SecretRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request: " + err.Error()})
+		return
+	}
+
+	sealed, err := box.Seal(name, []byte(req.Value))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to encrypt secret"})
+		return
+	}
+
+	secret, err := s.DB.UpsertSecret(c, database.UpsertSecretParams{
+		Name:  name,
+		Value: sealed,
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to store secret"})
+		return
+	}
+
+	c.JSON(http.StatusOK, secretToResponse(secret))
+}
+
+// @Summary List secrets
+// @Description Lists the names of stored secrets
+// @Tags Secrets
+// @Success 200 {object} entity.ListSecretsResponse
+// @Failure 500 {object} map[string]string
+// @Router /secrets [get]
+func (s *Server) ListSecrets(c *gin.Context) {
+	secrets, err := s.DB.ListSecrets(c)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch secrets"})
+		return
+	}
+
+	responses := []entity.SecretResponse{}
+	for _, secret := range secrets {
+		responses = append(responses, entity.SecretResponse{
+			ID:        secret.ID,
+			Name:      secret.Name,
+			CreatedAt: secret.CreatedAt.Time,
+			UpdatedAt: secret.UpdatedAt.Time,
+		})
+	}
+
+	c.JSON(http.StatusOK, entity.ListSecretsResponse{Secrets: responses})
+}
+
+// @Summary Delete a secret
+// @Description Deletes a secret. Tasks that still reference it fail until it is stored again
+// @Tags Secrets
+// @Param name path string true "Secret name"
+// @Success 200 {object} entity.SecretResponse
+// @Failure 404 {object} map[string]string
+// @Router /secrets/{name} [delete]
+func (s *Server) DeleteSecret(c *gin.Context) {
+	secret, err := s.DB.DeleteSecret(c, c.Param("name"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Secret not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, secretToResponse(secret))
+}
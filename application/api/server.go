@@ -1,7 +1,9 @@
 package api
 
 import (
+	"scheduler/clock"
 	"scheduler/database"
+	"scheduler/workers"
 	"time"
 
 	"github.com/gin-contrib/cors"
@@ -13,9 +15,11 @@ import (
 type Server struct {
 	Router *gin.Engine
 	DB     *database.Queries
+	Clock  clock.Clock
+	Pool   *workers.WorkerPool
 }
 
-func NewServer(db *database.Queries) *Server {
+func NewServer(db *database.Queries, clk clock.Clock, pool *workers.WorkerPool) *Server {
 	router := gin.Default()
 	router.Use(cors.New(cors.Config{
 		AllowOrigins:     []string{"http://localhost:5173"},
@@ -27,6 +31,8 @@ func NewServer(db *database.Queries) *Server {
 	s := &Server{
 		Router: router,
 		DB:     db,
+		Clock:  clk,
+		Pool:   pool,
 	}
 	return s
 }
@@ -39,7 +45,27 @@ func (s *Server) SetRoutes() {
 	r.PUT("/tasks/:id", s.UpdateTask)
 	r.DELETE("/tasks/:id", s.CancelTask)
 	r.GET("/tasks/:id/results", s.ListTaskResults)
+	r.GET("/tasks/:id/runs", s.ListTaskRuns)
+	r.POST("/tasks/:id/resume", s.ResumeTask)
 	r.GET("/results", s.ListAllTasksResults)
+	r.GET("/forecast", s.Forecast)
+	r.GET("/dead-letters", s.ListDeadLetters)
+	r.GET("/dead-letters/:id", s.GetDeadLetter)
+	r.POST("/dead-letters/:id/requeue", s.RequeueDeadLetter)
+	r.DELETE("/dead-letters/:id", s.DiscardDeadLetter)
+	r.GET("/descriptor-sets", s.ListDescriptorSets)
+	r.PUT("/descriptor-sets/:name", s.UploadDescriptorSet)
+	r.DELETE("/descriptor-sets/:name", s.DeleteDescriptorSet)
+	r.GET("/secrets", s.ListSecrets)
+	r.PUT("/secrets/:name", s.PutSecret)
+	r.DELETE("/secrets/:name", s.DeleteSecret)
+	r.GET("/tls-profiles", s.ListTLSProfiles)
+	r.PUT("/tls-profiles/:name", s.PutTLSProfile)
+	r.DELETE("/tls-profiles/:name", s.DeleteTLSProfile)
+	r.GET("/admin/workers", s.GetWorkers)
+	r.PUT("/admin/workers", s.ResizeWorkers)
+	r.GET("/admin/breakers", s.ListBreakers)
+	r.DELETE("/admin/breakers/:host", s.ResetBreaker)
 	r.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
 
 }
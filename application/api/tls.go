@@ -0,0 +1,115 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	entity "scheduler/application/entity"
+	"scheduler/database"
+	"scheduler/workers"
+
+	"github.com/gin-gonic/gin"
+)
+
+// @Summary Store a TLS profile
+// @Description Stores a reusable TLS profile under the name, replacing any existing one. Tasks use it with tls.profile; fields set on the task take precedence
+// @Tags TLS Profiles
+// @Accept json
+// @Produce json
+// @Param name path string true "Profile name"
+// @Param profile body entity.TLSConfig true "TLS settings"
+// @Success 200 {object} entity.TLSProfileResponse
+// @Failure 400 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /tls-profiles/{name} [put]
+func (s *Server) PutTLSProfile(c *gin.Context) {
+	name := c.Param("name")
+	if !secretNamePattern.MatchString(name) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Profile names may only contain letters, digits, '_', '.' and '-'"})
+		return
+	}
+
+	var req entity.TLSConfig
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request: " + err.Error()})
+		return
+	}
+	if req.Profile != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Profiles cannot reference other profiles"})
+		return
+	}
+	if err := workers.ValidateTLS(workers.DefaultActionType, req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid tls config: " + err.Error()})
+		return
+	}
+	if req.ClientKeyRef != "" {
+		if err := s.checkSecrets(c, req.ClientKeyRef); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid tls config: " + err.Error()})
+			return
+		}
+	}
+
+	config, _ := json.Marshal(req)
+	profile, err := s.DB.UpsertTLSProfile(c, database.UpsertTLSProfileParams{
+		Name:   name,
+		Config: config,
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to store tls profile"})
+		return
+	}
+
+	response, err := tlsProfileToResponse(profile)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to format response"})
+		return
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// @Summary List TLS profiles
+// @Description Lists stored TLS profiles
+// @Tags TLS Profiles
+// @Success 200 {object} entity.ListTLSProfilesResponse
+// @Failure 500 {object} map[string]string
+// @Router /tls-profiles [get]
+func (s *Server) ListTLSProfiles(c *gin.Context) {
+	profiles, err := s.DB.ListTLSProfiles(c)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch tls profiles"})
+		return
+	}
+
+	responses := []entity.TLSProfileResponse{}
+	for _, profile := range profiles {
+		response, err := tlsProfileToResponse(profile)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to format response"})
+			return
+		}
+		responses = append(responses, response)
+	}
+
+	c.JSON(http.StatusOK, entity.ListTLSProfilesResponse{Profiles: responses})
+}
+
+// @Summary Delete a TLS profile
+// @Description Deletes a TLS profile. Tasks that still reference it fail until it is stored again
+// @Tags TLS Profiles
+// @Param name path string true "Profile name"
+// @Success 200 {object} entity.TLSProfileResponse
+// @Failure 404 {object} map[string]string
+// @Router /tls-profiles/{name} [delete]
+func (s *Server) DeleteTLSProfile(c *gin.Context) {
+	profile, err := s.DB.DeleteTLSProfile(c, c.Param("name"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "TLS profile not found"})
+		return
+	}
+
+	response, err := tlsProfileToResponse(profile)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to format response"})
+		return
+	}
+	c.JSON(http.StatusOK, response)
+}
@@ -1,7 +1,29 @@
 package entity
 
+import "encoding/json"
+
+// ActionData describes what a task does. URL, header values and strings in
+// the payload may contain text/template expressions, which are rendered with
+// the run's variables before every attempt.
 type ActionData struct {
-	Method  string            `json:"method" binding:"required"`
+	Type        string            `json:"type,omitempty"`
+	Method      string            `json:"method,omitempty" binding:"omitempty,oneof=GET POST PUT DELETE PATCH HEAD"`
+	URL         string            `json:"url,omitempty"`
+	Headers     map[string]string `json:"headers,omitempty"`
+	Payload     interface{}       `json:"payload,omitempty"`
+	Config      json.RawMessage   `json:"config,omitempty"`
+	Auth        *AuthConfig       `json:"auth,omitempty"`
+	Proxy       string            `json:"proxy,omitempty"`
+	Targets     []TargetData      `json:"targets,omitempty" binding:"omitempty,dive"`
+	Mode        string            `json:"mode,omitempty" binding:"omitempty,oneof=parallel sequential"`
+	SuccessRule string            `json:"success_rule,omitempty" binding:"omitempty,oneof=all any quorum"`
+	Quorum      int               `json:"quorum,omitempty" binding:"omitempty,min=1"`
+}
+
+// TargetData is one endpoint of a fan-out action. Empty fields fall back to
+// the values on the enclosing ActionData, and headers are merged over it.
+type TargetData struct {
+	Method  string            `json:"method,omitempty"`
 	URL     string            `json:"url" binding:"required"`
 	Headers map[string]string `json:"headers,omitempty"`
 	Payload interface{}       `json:"payload,omitempty"`
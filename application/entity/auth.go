@@ -0,0 +1,24 @@
+package entity
+
+// AuthConfig authenticates the requests of an http action. Credentials are
+// referenced by the names of stored secrets rather than included inline.
+//
+// For "oauth2" the worker fetches a token from TokenURL with the client
+// credentials grant, caches it per credential until shortly before it
+// expires and sends it as a bearer token. For "basic" it sends Username and
+// the password secret with HTTP basic auth.
+type AuthConfig struct {
+	Type string `json:"type" binding:"required,oneof=oauth2 basic"`
+
+	TokenURL        string   `json:"token_url,omitempty"`
+	ClientID        string   `json:"client_id,omitempty"`
+	ClientSecretRef string   `json:"client_secret_ref,omitempty"`
+	Scopes          []string `json:"scopes,omitempty"`
+	Audience        string   `json:"audience,omitempty"`
+	// CredentialsInBody sends the client credentials as form fields instead
+	// of basic auth, for token endpoints that require it.
+	CredentialsInBody bool `json:"credentials_in_body,omitempty"`
+
+	Username    string `json:"username,omitempty"`
+	PasswordRef string `json:"password_ref,omitempty"`
+}
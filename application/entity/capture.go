@@ -0,0 +1,11 @@
+package entity
+
+// CaptureConfig limits how much of a response is stored with a result.
+// MaxBodyBytes can only lower the server-wide limit, and DiscardBody keeps
+// bodies out of results entirely. Headers, when set, is the allowlist of
+// response headers to store; assertions still see every header.
+type CaptureConfig struct {
+	MaxBodyBytes int64    `json:"max_body_bytes,omitempty" binding:"omitempty,min=0"`
+	DiscardBody  bool     `json:"discard_body,omitempty"`
+	Headers      []string `json:"headers,omitempty" binding:"omitempty,dive,required"`
+}
@@ -0,0 +1,25 @@
+package entity
+
+// CommandConfig is the config of a "command" action. Path must be on the
+// server's allowlist and is run directly, without a shell. The worker's own
+// environment is not inherited, only Env is passed to the process.
+type CommandConfig struct {
+	Path           string            `json:"path"`
+	Args           []string          `json:"args,omitempty"`
+	Env            map[string]string `json:"env,omitempty"`
+	Dir            string            `json:"dir,omitempty"`
+	Stdin          string            `json:"stdin,omitempty"`
+	MaxOutputBytes int64             `json:"max_output_bytes,omitempty"`
+	User           string            `json:"user,omitempty"`
+	Limits         *CommandLimits    `json:"limits,omitempty"`
+}
+
+// CommandLimits are resource limits applied to the process. Zero leaves the
+// limit inherited from the worker.
+type CommandLimits struct {
+	CPUSeconds  uint64 `json:"cpu_seconds,omitempty"`
+	MemoryBytes uint64 `json:"memory_bytes,omitempty"`
+	OpenFiles   uint64 `json:"open_files,omitempty"`
+	Processes   uint64 `json:"processes,omitempty"`
+	FileBytes   uint64 `json:"file_bytes,omitempty"`
+}
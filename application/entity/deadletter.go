@@ -0,0 +1,46 @@
+package entity
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// DeadLetterRequest is the request as it was last sent to the target. For
+// actions other than HTTP, Config holds the action config that was run.
+type DeadLetterRequest struct {
+	Method  string            `json:"method,omitempty"`
+	URL     string            `json:"url,omitempty"`
+	Headers map[string]string `json:"headers,omitempty"`
+	Body    json.RawMessage   `json:"body,omitempty"`
+	Config  json.RawMessage   `json:"config,omitempty"`
+}
+
+type DeadLetterResponse struct {
+	ID           pgtype.UUID       `json:"id"`
+	TaskID       pgtype.UUID       `json:"task_id"`
+	RunID        pgtype.UUID       `json:"run_id"`
+	TargetIndex  int32             `json:"target_index"`
+	Request      DeadLetterRequest `json:"request"`
+	StatusCode   int32             `json:"status_code"`
+	ErrorMessage string            `json:"error_message,omitempty"`
+	ErrorClass   string            `json:"error_class,omitempty"`
+	Attempts     int32             `json:"attempts"`
+	Status       string            `json:"status"`
+	CreatedAt    time.Time         `json:"created_at"`
+	UpdatedAt    time.Time         `json:"updated_at"`
+}
+
+// RequeueDeadLetterResponse reports a replay that was started. TaskStatus is
+// the task's status, which a replay doesn't change: a paused task stays
+// paused.
+type RequeueDeadLetterResponse struct {
+	DeadLetter  DeadLetterResponse `json:"dead_letter"`
+	ReplayRunID pgtype.UUID        `json:"replay_run_id"`
+	TaskStatus  string             `json:"task_status"`
+}
+
+type ListDeadLettersResponse struct {
+	DeadLetters []DeadLetterResponse `json:"dead_letters"`
+}
@@ -0,0 +1,17 @@
+package entity
+
+// EmailConfig is the config of an "email" action. Subject and Text are Go
+// text templates and HTML is an html/template. They see the same run
+// variables and helpers as action templates, secret included, with Data
+// under .Data. From defaults to the server's sender.
+type EmailConfig struct {
+	From    string                 `json:"from,omitempty"`
+	To      []string               `json:"to"`
+	Cc      []string               `json:"cc,omitempty"`
+	Bcc     []string               `json:"bcc,omitempty"`
+	ReplyTo string                 `json:"reply_to,omitempty"`
+	Subject string                 `json:"subject"`
+	Text    string                 `json:"text,omitempty"`
+	HTML    string                 `json:"html,omitempty"`
+	Data    map[string]interface{} `json:"data,omitempty"`
+}
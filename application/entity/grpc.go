@@ -0,0 +1,41 @@
+package entity
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// GRPCConfig is the config of a "grpc" action. Method is the full method
+// name, e.g. "pkg.Service/Method". Its schema comes from the named
+// descriptor set, or from the server's reflection service when none is set.
+type GRPCConfig struct {
+	Target        string            `json:"target"`
+	Method        string            `json:"method"`
+	Request       json.RawMessage   `json:"request,omitempty"`
+	Metadata      map[string]string `json:"metadata,omitempty"`
+	DescriptorSet string            `json:"descriptor_set,omitempty"`
+	TLS           *GRPCTLSConfig    `json:"tls,omitempty"`
+}
+
+// GRPCTLSConfig enables TLS for a grpc action; without it the connection is
+// plaintext. CACert is a PEM bundle used instead of the system roots.
+type GRPCTLSConfig struct {
+	ServerName         string `json:"server_name,omitempty"`
+	CACert             string `json:"ca_cert,omitempty"`
+	InsecureSkipVerify bool   `json:"insecure_skip_verify,omitempty"`
+}
+
+type DescriptorSetResponse struct {
+	ID        pgtype.UUID `json:"id"`
+	Name      string      `json:"name"`
+	Services  []string    `json:"services"`
+	Size      int32       `json:"size"`
+	CreatedAt time.Time   `json:"created_at"`
+	UpdatedAt time.Time   `json:"updated_at"`
+}
+
+type ListDescriptorSetsResponse struct {
+	DescriptorSets []DescriptorSetResponse `json:"descriptor_sets"`
+}
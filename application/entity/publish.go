@@ -0,0 +1,24 @@
+package entity
+
+// PublishConfig is the config of a "publish" action. The message body is the
+// action's payload and its headers are the action's headers, overridden by
+// Headers here. Broker is "nats" or "amqp"; the connections themselves are
+// configured on the server.
+type PublishConfig struct {
+	Broker  string            `json:"broker"`
+	Headers map[string]string `json:"headers,omitempty"`
+
+	// Subject is the NATS subject. With JetStream set the message is
+	// published to the stream capturing the subject and its ack is recorded.
+	Subject   string `json:"subject,omitempty"`
+	JetStream bool   `json:"jetstream,omitempty"`
+
+	// Exchange and RoutingKey address the AMQP message; an empty exchange is
+	// the default exchange, which routes to the queue named by RoutingKey.
+	Exchange    string `json:"exchange,omitempty"`
+	RoutingKey  string `json:"routing_key,omitempty"`
+	ContentType string `json:"content_type,omitempty"`
+	Persistent  bool   `json:"persistent,omitempty"`
+	// Mandatory fails the publish when no queue is bound for the routing key.
+	Mandatory bool `json:"mandatory,omitempty"`
+}
@@ -0,0 +1,19 @@
+package entity
+
+// RetryPolicy controls how many times a failed target is attempted within a
+// single run. Delays grow by Multiplier from InitialDelayMs up to MaxDelayMs,
+// with Jitter spreading each delay by up to that fraction either way. A
+// Retry-After longer than MaxDelayMs ends the retries.
+//
+// RetryOnNetworkError defaults to true, except for sql actions: a statement
+// whose connection dropped may have committed, so retrying it runs it at
+// least once rather than exactly once.
+type RetryPolicy struct {
+	MaxAttempts         int     `json:"max_attempts" binding:"required,min=1"`
+	InitialDelayMs      int     `json:"initial_delay_ms,omitempty" binding:"omitempty,min=0"`
+	Multiplier          float64 `json:"multiplier,omitempty" binding:"omitempty,min=1"`
+	MaxDelayMs          int     `json:"max_delay_ms,omitempty" binding:"omitempty,min=0"`
+	Jitter              float64 `json:"jitter,omitempty" binding:"omitempty,min=0,max=1"`
+	RetryOnStatus       []int   `json:"retry_on_status,omitempty"`
+	RetryOnNetworkError *bool   `json:"retry_on_network_error,omitempty"`
+}
@@ -0,0 +1,24 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+type SecretRequest struct {
+	Value string `json:"value" binding:"required"`
+}
+
+// SecretResponse describes a stored secret. Values are write-only and never
+// included.
+type SecretResponse struct {
+	ID        pgtype.UUID `json:"id"`
+	Name      string      `json:"name"`
+	CreatedAt time.Time   `json:"created_at"`
+	UpdatedAt time.Time   `json:"updated_at"`
+}
+
+type ListSecretsResponse struct {
+	Secrets []SecretResponse `json:"secrets"`
+}
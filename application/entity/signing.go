@@ -0,0 +1,11 @@
+package entity
+
+// SigningConfig makes the worker sign every request of an http task with
+// HMAC-SHA256, see the signature package. Secret names the stored secret
+// holding the shared key; the header names default to
+// X-Scheduler-Signature and X-Scheduler-Timestamp.
+type SigningConfig struct {
+	Secret          string `json:"secret" binding:"required"`
+	SignatureHeader string `json:"signature_header,omitempty"`
+	TimestampHeader string `json:"timestamp_header,omitempty"`
+}
@@ -0,0 +1,15 @@
+package entity
+
+// SQLConfig is the config of a "sql" action. Connection names one of the
+// connections configured on the server, so DSNs never appear in tasks.
+// TimeoutMs defaults to the task's total timeout. Statements are retried
+// after serialization failures and timeouts, which roll them back, but not
+// after a lost connection unless the retry policy sets
+// retry_on_network_error.
+type SQLConfig struct {
+	Connection string        `json:"connection"`
+	Statement  string        `json:"statement"`
+	Args       []interface{} `json:"args,omitempty"`
+	MaxRows    int           `json:"max_rows,omitempty"`
+	TimeoutMs  int           `json:"timeout_ms,omitempty"`
+}
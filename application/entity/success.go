@@ -0,0 +1,20 @@
+package entity
+
+// SuccessCriteria decides whether a response counts as a successful run.
+// Without it any 2xx response succeeds. StatusCodes are in the action type's
+// own codes: HTTP statuses, gRPC codes or SMTP replies.
+type SuccessCriteria struct {
+	StatusCodes     []int             `json:"status_codes,omitempty"`
+	BodyAssertions  []BodyAssertion   `json:"body_assertions,omitempty" binding:"omitempty,dive"`
+	RequiredHeaders map[string]string `json:"required_headers,omitempty"`
+	MaxLatencyMs    int               `json:"max_latency_ms,omitempty" binding:"omitempty,min=0"`
+}
+
+// BodyAssertion checks the response body. With JSONPath set the value at
+// that path must exist and, if given, equal Equals and match Regex. With only
+// Regex set the raw body must match it.
+type BodyAssertion struct {
+	JSONPath string      `json:"jsonpath,omitempty"`
+	Regex    string      `json:"regex,omitempty"`
+	Equals   interface{} `json:"equals,omitempty"`
+}
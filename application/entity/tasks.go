@@ -7,26 +7,48 @@ import (
 )
 
 type CreateTaskReq struct {
-	Name    string      `json:"name"`
-	Trigger TriggerData `json:"trigger"`
-	Action  ActionData  `json:"action"`
+	Name               string           `json:"name"`
+	Trigger            TriggerData      `json:"trigger"`
+	Action             ActionData       `json:"action"`
+	Retry              *RetryPolicy     `json:"retry,omitempty"`
+	Timeouts           *TimeoutConfig   `json:"timeouts,omitempty"`
+	Success            *SuccessCriteria `json:"success,omitempty"`
+	Capture            *CaptureConfig   `json:"capture,omitempty"`
+	Signing            *SigningConfig   `json:"signing,omitempty"`
+	TLS                *TLSConfig       `json:"tls,omitempty"`
+	PauseAfterFailures int              `json:"pause_after_failures,omitempty" binding:"omitempty,min=0"`
 }
 
 type TaskResponse struct {
-	ID        pgtype.UUID `json:"id"`
-	Name      string      `json:"name"`
-	Trigger   TriggerData `json:"trigger"`
-	Action    ActionData  `json:"action"`
-	Status    string      `json:"status"`
-	CreatedAt time.Time   `json:"created_at"`
-	UpdatedAt time.Time   `json:"updated_at"`
-	NextRun   *time.Time  `json:"next_run"`
+	ID                  pgtype.UUID      `json:"id"`
+	Name                string           `json:"name"`
+	Trigger             TriggerData      `json:"trigger"`
+	Action              ActionData       `json:"action"`
+	Retry               *RetryPolicy     `json:"retry,omitempty"`
+	Timeouts            *TimeoutConfig   `json:"timeouts,omitempty"`
+	Success             *SuccessCriteria `json:"success,omitempty"`
+	Capture             *CaptureConfig   `json:"capture,omitempty"`
+	Signing             *SigningConfig   `json:"signing,omitempty"`
+	TLS                 *TLSConfig       `json:"tls,omitempty"`
+	PauseAfterFailures  int32            `json:"pause_after_failures"`
+	ConsecutiveFailures int32            `json:"consecutive_failures"`
+	Status              string           `json:"status"`
+	CreatedAt           time.Time        `json:"created_at"`
+	UpdatedAt           time.Time        `json:"updated_at"`
+	NextRun             *time.Time       `json:"next_run"`
 }
 
 type UpdateTaskRequest struct {
-	Name    *string      `json:"name"`
-	Trigger *TriggerData `json:"trigger"`
-	Action  *ActionData  `json:"action"`
+	Name               *string          `json:"name"`
+	Trigger            *TriggerData     `json:"trigger"`
+	Action             *ActionData      `json:"action"`
+	Retry              *RetryPolicy     `json:"retry"`
+	Timeouts           *TimeoutConfig   `json:"timeouts"`
+	Success            *SuccessCriteria `json:"success"`
+	Capture            *CaptureConfig   `json:"capture"`
+	Signing            *SigningConfig   `json:"signing"`
+	TLS                *TLSConfig       `json:"tls"`
+	PauseAfterFailures *int             `json:"pause_after_failures" binding:"omitempty,min=0"`
 }
 
 type ListTasksResponse struct {
@@ -36,12 +58,61 @@ type ListTasksResponse struct {
 type TaskResultResponse struct {
 	ID              pgtype.UUID            `json:"id"`
 	TaskID          pgtype.UUID            `json:"task_id"`
+	RunID           pgtype.UUID            `json:"run_id"`
+	TargetIndex     int32                  `json:"target_index"`
+	TargetURL       string                 `json:"target_url,omitempty"`
+	Attempt         int32                  `json:"attempt"`
 	RunAt           time.Time              `json:"run_at"`
 	StatusCode      int32                  `json:"status_code"`
 	Success         bool                   `json:"success"`
 	ResponseHeaders map[string]interface{} `json:"response_headers,omitempty"`
 	ResponseBody    interface{}            `json:"response_body,omitempty"`
+	BodyEncoding    string                 `json:"body_encoding,omitempty"`
+	BodyTruncated   bool                   `json:"body_truncated"`
 	ErrorMessage    string                 `json:"error_message,omitempty"`
+	ErrorClass      string                 `json:"error_class,omitempty"`
+	IdempotencyKey  string                 `json:"idempotency_key,omitempty"`
+	ExitCode        *int32                 `json:"exit_code,omitempty"`
+	Stderr          string                 `json:"stderr,omitempty"`
 	DurationMs      int32                  `json:"duration_ms"`
 	CreatedAt       time.Time              `json:"created_at"`
 }
+
+type TaskRunResponse struct {
+	ID               pgtype.UUID          `json:"id"`
+	TaskID           pgtype.UUID          `json:"task_id"`
+	RunNumber        int32                `json:"run_number"`
+	Status           string               `json:"status"`
+	SuccessRule      string               `json:"success_rule"`
+	TargetsTotal     int32                `json:"targets_total"`
+	TargetsSucceeded int32                `json:"targets_succeeded"`
+	StartedAt        time.Time            `json:"started_at"`
+	ScheduledAt      *time.Time           `json:"scheduled_at,omitempty"`
+	FinishedAt       *time.Time           `json:"finished_at,omitempty"`
+	Results          []TaskResultResponse `json:"results"`
+}
+
+type ForecastResponse struct {
+	From            time.Time                `json:"from"`
+	To              time.Time                `json:"to"`
+	Bucket          string                   `json:"bucket"`
+	Workers         int                      `json:"workers"`
+	TotalRuns       int                      `json:"total_runs"`
+	PeakConcurrency int                      `json:"peak_concurrency"`
+	Buckets         []ForecastBucketResponse `json:"buckets"`
+	Peaks           []ForecastBucketResponse `json:"peaks"`
+}
+
+type ForecastBucketResponse struct {
+	Start           time.Time              `json:"start"`
+	Runs            int                    `json:"runs"`
+	PeakConcurrency int                    `json:"peak_concurrency"`
+	Queued          int                    `json:"queued"`
+	Tasks           []ForecastTaskResponse `json:"tasks,omitempty"`
+}
+
+type ForecastTaskResponse struct {
+	ID   pgtype.UUID `json:"id"`
+	Name string      `json:"name"`
+	Runs int         `json:"runs"`
+}
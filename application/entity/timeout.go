@@ -0,0 +1,11 @@
+package entity
+
+// TimeoutConfig bounds a task's requests. ConnectMs and ReadMs limit dialing
+// and waiting for response headers, TotalMs limits a single attempt end to
+// end and RunMs limits the whole run across all targets and retries.
+type TimeoutConfig struct {
+	ConnectMs int `json:"connect_ms,omitempty" binding:"omitempty,min=0"`
+	ReadMs    int `json:"read_ms,omitempty" binding:"omitempty,min=0"`
+	TotalMs   int `json:"total_ms,omitempty" binding:"omitempty,min=0"`
+	RunMs     int `json:"run_ms,omitempty" binding:"omitempty,min=0"`
+}
@@ -0,0 +1,34 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// TLSConfig customizes TLS for an http task. Profile names a stored profile
+// whose settings are used for any field left empty here. CACert is a PEM
+// bundle trusted in addition to the system roots; ClientCert is a PEM
+// certificate chain whose key is read from the secret named by ClientKeyRef.
+// MinVersion is "1.2" or "1.3" and defaults to "1.2".
+type TLSConfig struct {
+	Profile            string `json:"profile,omitempty"`
+	CACert             string `json:"ca_cert,omitempty"`
+	ClientCert         string `json:"client_cert,omitempty"`
+	ClientKeyRef       string `json:"client_key_ref,omitempty"`
+	ServerName         string `json:"server_name,omitempty"`
+	MinVersion         string `json:"min_version,omitempty" binding:"omitempty,oneof=1.2 1.3"`
+	InsecureSkipVerify bool   `json:"insecure_skip_verify,omitempty"`
+}
+
+type TLSProfileResponse struct {
+	ID        pgtype.UUID `json:"id"`
+	Name      string      `json:"name"`
+	Config    TLSConfig   `json:"config"`
+	CreatedAt time.Time   `json:"created_at"`
+	UpdatedAt time.Time   `json:"updated_at"`
+}
+
+type ListTLSProfilesResponse struct {
+	Profiles []TLSProfileResponse `json:"profiles"`
+}
@@ -1,7 +1,9 @@
 package entity
 
 type TriggerData struct {
-	Type     string `json:"type" binding:"required,oneof=one-off cron"`
+	Type     string `json:"type" binding:"required,oneof=one-off cron rrule"`
 	DateTime string `json:"datetime,omitempty"`
 	Cron     string `json:"cron,omitempty"`
+	RRule    string `json:"rrule,omitempty"`
+	Timezone string `json:"timezone,omitempty"`
 }
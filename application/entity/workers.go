@@ -0,0 +1,44 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// WorkerStatus is what one worker is doing. State is idle, busy or
+// stopping; Since is when it entered that state.
+type WorkerStatus struct {
+	ID       int          `json:"id"`
+	State    string       `json:"state"`
+	TaskID   *pgtype.UUID `json:"task_id,omitempty"`
+	TaskName string       `json:"task_name,omitempty"`
+	Since    time.Time    `json:"since"`
+}
+
+type WorkerPoolStatus struct {
+	Min        int            `json:"min"`
+	Max        int            `json:"max"`
+	Size       int            `json:"size"`
+	Busy       int            `json:"busy"`
+	Idle       int            `json:"idle"`
+	QueueDepth int            `json:"queue_depth"`
+	Workers    []WorkerStatus `json:"workers"`
+}
+
+type ResizePoolRequest struct {
+	Min int `json:"min" binding:"required,min=1"`
+	Max int `json:"max" binding:"required,gtefield=Min"`
+}
+
+// BreakerStatus is the circuit state of a host with recent failures.
+// OpenedAt and RetryAt are set once the circuit has opened; RetryAt is when a
+// probe request is next let through.
+type BreakerStatus struct {
+	Host      string     `json:"host"`
+	State     string     `json:"state"`
+	Failures  int        `json:"failures"`
+	LastError string     `json:"last_error,omitempty"`
+	OpenedAt  *time.Time `json:"opened_at,omitempty"`
+	RetryAt   *time.Time `json:"retry_at,omitempty"`
+}
@@ -0,0 +1,43 @@
+package clock
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFakeTicker(t *testing.T) {
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	clk := NewFake(start)
+	ticker := clk.NewTicker(time.Minute)
+
+	clk.Advance(59 * time.Second)
+	select {
+	case tick := <-ticker.C():
+		t.Fatalf("ticked early at %s", tick)
+	default:
+	}
+
+	clk.Advance(time.Second)
+	if tick := <-ticker.C(); !tick.Equal(start.Add(time.Minute)) {
+		t.Errorf("tick = %s, want %s", tick, start.Add(time.Minute))
+	}
+
+	// Ticks nobody receives are dropped, as with time.Ticker.
+	clk.Advance(10 * time.Minute)
+	if tick := <-ticker.C(); !tick.Equal(start.Add(2 * time.Minute)) {
+		t.Errorf("tick = %s, want %s", tick, start.Add(2*time.Minute))
+	}
+	select {
+	case tick := <-ticker.C():
+		t.Fatalf("unexpected tick %s", tick)
+	default:
+	}
+
+	ticker.Stop()
+	clk.Advance(time.Hour)
+	select {
+	case tick := <-ticker.C():
+		t.Fatalf("stopped ticker ticked at %s", tick)
+	default:
+	}
+}
@@ -1,6 +1,6 @@
 -- name: CreateTask :one
-INSERT INTO tasks (name, trigger_type, trigger_datetime, trigger_cron, action_method, action_url, action_headers, action_payload, status, next_run)
-VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+INSERT INTO tasks (name, trigger_type, trigger_datetime, trigger_cron, action_method, action_url, action_headers, action_payload, action_targets, action_mode, action_success_rule, action_quorum, status, next_run, trigger_rrule, trigger_timezone, retry_policy, timeouts, pause_after_failures, success_criteria, capture, action_type, action_config, signing, action_auth, tls, action_proxy)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20, $21, $22, $23, $24, $25, $26, $27)
 RETURNING *;
 
 
@@ -33,6 +33,23 @@ SET name = COALESCE($2, name),
     action_payload = COALESCE($9, action_payload),
     status = COALESCE($10, status),
     next_run = COALESCE($11, next_run),
+    action_targets = COALESCE($12, action_targets),
+    action_mode = COALESCE($13, action_mode),
+    action_success_rule = COALESCE($14, action_success_rule),
+    action_quorum = COALESCE($15, action_quorum),
+    trigger_rrule = COALESCE($16, trigger_rrule),
+    trigger_timezone = COALESCE($17, trigger_timezone),
+    retry_policy = COALESCE($18, retry_policy),
+    timeouts = COALESCE($19, timeouts),
+    pause_after_failures = COALESCE($20, pause_after_failures),
+    success_criteria = COALESCE($21, success_criteria),
+    capture = COALESCE($22, capture),
+    action_type = COALESCE($23, action_type),
+    action_config = COALESCE($24, action_config),
+    signing = COALESCE($25, signing),
+    action_auth = COALESCE($26, action_auth),
+    tls = COALESCE($27, tls),
+    action_proxy = COALESCE($28, action_proxy),
     updated_at = now()
 WHERE id = $1
 RETURNING *;
@@ -54,9 +71,26 @@ WHERE status = 'scheduled'
 ORDER BY next_run ASC;
 
 
+-- name: SetTaskNextRun :one
+UPDATE tasks
+SET next_run = $2,
+    updated_at = now()
+WHERE id = $1
+RETURNING *;
+
+
+-- name: RestoreTaskNextRun :exec
+UPDATE tasks
+SET next_run = $2,
+    updated_at = now()
+WHERE id = $1
+  AND status = 'scheduled'
+  AND (next_run IS NULL OR next_run > $2);
+
+
 -- name: CreateTaskResult :one
-INSERT INTO task_results (task_id,run_at,status_code,success,response_headers,response_body,error_message,duration_ms,created_at)
-VALUES ($1, $2, $3, $4, $5, $6, $7, $8, now())
+INSERT INTO task_results (task_id,run_id,target_index,target_url,attempt,run_at,status_code,success,response_headers,response_body,body_encoding,body_truncated,error_message,error_class,duration_ms,exit_code,stderr,idempotency_key,created_at)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, now())
 RETURNING *;
 
 
@@ -70,3 +104,187 @@ RETURNING *;
 
 -- name: ListAllTaskResults :many
 SELECT * FROM task_results;
+
+
+-- name: CreateTaskRun :one
+INSERT INTO task_runs (task_id, started_at, success_rule, targets_total, scheduled_at, run_number)
+VALUES ($1, $2, $3, $4, $5, (SELECT COUNT(*) + 1 FROM task_runs WHERE task_id = $1))
+RETURNING *;
+
+
+-- name: FinishTaskRun :one
+UPDATE task_runs
+SET status = $2,
+    finished_at = $3,
+    targets_succeeded = $4
+WHERE id = $1
+RETURNING *;
+
+
+-- name: GetTaskRun :one
+SELECT * FROM task_runs
+WHERE id = $1;
+
+
+-- name: ListTaskRuns :many
+SELECT * FROM task_runs
+WHERE task_id = $1
+ORDER BY started_at DESC;
+
+
+-- name: ListActiveTasks :many
+SELECT * FROM tasks
+WHERE status = 'scheduled'
+  AND next_run IS NOT NULL;
+
+
+-- name: ListAverageDurations :many
+SELECT task_id, AVG(duration_ms)::INT AS avg_duration_ms
+FROM task_results
+GROUP BY task_id;
+
+
+-- name: RescheduleTask :one
+UPDATE tasks
+SET status = 'scheduled',
+    next_run = $2,
+    consecutive_failures = 0,
+    updated_at = now()
+WHERE id = $1
+RETURNING *;
+
+
+-- name: RecordTaskFailure :one
+UPDATE tasks
+SET consecutive_failures = consecutive_failures + 1,
+    status = CASE
+        WHEN status = 'scheduled' AND pause_after_failures > 0 AND consecutive_failures + 1 >= pause_after_failures THEN 'paused'
+        ELSE status
+    END,
+    updated_at = now()
+WHERE id = $1
+RETURNING *;
+
+
+-- name: ResetTaskFailures :one
+UPDATE tasks
+SET consecutive_failures = 0
+WHERE id = $1
+RETURNING *;
+
+
+-- name: CreateDeadLetter :one
+INSERT INTO dead_letters (task_id, run_id, target_index, request, status_code, error_message, error_class, attempts)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+RETURNING *;
+
+
+-- name: GetDeadLetter :one
+SELECT * FROM dead_letters
+WHERE id = $1;
+
+
+-- name: ListDeadLetters :many
+SELECT * FROM dead_letters
+WHERE ($1::TEXT IS NULL OR $1 = '' OR status = $1)
+ORDER BY created_at DESC
+LIMIT $2 OFFSET $3;
+
+
+-- name: MarkDeadLetterRequeued :one
+UPDATE dead_letters
+SET status = 'requeued',
+    updated_at = now()
+WHERE id = $1
+RETURNING *;
+
+
+-- name: DeleteDeadLetter :one
+DELETE FROM dead_letters
+WHERE id = $1
+RETURNING *;
+
+
+-- name: UpsertDescriptorSet :one
+INSERT INTO descriptor_sets (name, data, services)
+VALUES ($1, $2, $3)
+ON CONFLICT (name) DO UPDATE
+SET data = EXCLUDED.data,
+    services = EXCLUDED.services,
+    updated_at = now()
+RETURNING *;
+
+
+-- name: GetDescriptorSet :one
+SELECT * FROM descriptor_sets
+WHERE name = $1;
+
+
+-- name: ListDescriptorSets :many
+SELECT id, name, services, octet_length(data) AS size, created_at, updated_at
+FROM descriptor_sets
+ORDER BY name;
+
+
+-- name: DeleteDescriptorSet :one
+DELETE FROM descriptor_sets
+WHERE name = $1
+RETURNING *;
+
+
+-- name: GetLastTaskResult :one
+SELECT * FROM task_results
+WHERE task_id = $1
+ORDER BY created_at DESC
+LIMIT 1;
+
+
+-- name: UpsertSecret :one
+INSERT INTO secrets (name, value)
+VALUES ($1, $2)
+ON CONFLICT (name) DO UPDATE
+SET value = EXCLUDED.value,
+    updated_at = now()
+RETURNING *;
+
+
+-- name: GetSecret :one
+SELECT * FROM secrets
+WHERE name = $1;
+
+
+-- name: ListSecrets :many
+SELECT id, name, created_at, updated_at
+FROM secrets
+ORDER BY name;
+
+
+-- name: DeleteSecret :one
+DELETE FROM secrets
+WHERE name = $1
+RETURNING *;
+
+
+-- name: UpsertTLSProfile :one
+INSERT INTO tls_profiles (name, config)
+VALUES ($1, $2)
+ON CONFLICT (name) DO UPDATE
+SET config = EXCLUDED.config,
+    updated_at = now()
+RETURNING *;
+
+
+-- name: GetTLSProfile :one
+SELECT * FROM tls_profiles
+WHERE name = $1;
+
+
+-- name: ListTLSProfiles :many
+SELECT * FROM tls_profiles
+ORDER BY name;
+
+
+-- name: DeleteTLSProfile :one
+DELETE FROM tls_profiles
+WHERE name = $1
+RETURNING *;
@@ -2,16 +2,34 @@ CREATE TABLE IF NOT EXISTS tasks (
     id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
     name TEXT NOT NULL,
 
-    trigger_type TEXT NOT NULL CHECK (trigger_type IN ('one-off', 'cron')),
+    trigger_type TEXT NOT NULL CHECK (trigger_type IN ('one-off', 'cron', 'rrule')),
     trigger_datetime TIMESTAMPTZ,
     trigger_cron TEXT,
+    trigger_rrule TEXT,
+    trigger_timezone TEXT,
 
-    action_method TEXT NOT NULL  CHECK (action_method IN ('GET', 'POST', 'PUT', 'DELETE', 'PATCH', 'HEAD')),
-    action_url TEXT NOT NULL,
+    action_type TEXT NOT NULL DEFAULT 'http',
+    action_method TEXT NOT NULL DEFAULT '' CHECK (action_type <> 'http' OR action_method IN ('GET', 'POST', 'PUT', 'DELETE', 'PATCH', 'HEAD')),
+    action_url TEXT NOT NULL DEFAULT '',
     action_headers JSONB,
     action_payload JSONB,
+    action_config JSONB,
+    action_auth JSONB,
+    action_proxy TEXT,
+    action_targets JSONB,
+    action_mode TEXT NOT NULL DEFAULT 'parallel' CHECK (action_mode IN ('parallel', 'sequential')),
+    action_success_rule TEXT NOT NULL DEFAULT 'all' CHECK (action_success_rule IN ('all', 'any', 'quorum')),
+    action_quorum INT NOT NULL DEFAULT 0,
+    retry_policy JSONB,
+    timeouts JSONB,
+    success_criteria JSONB,
+    capture JSONB,
+    signing JSONB,
+    tls JSONB,
 
-    status TEXT NOT NULL DEFAULT 'scheduled'  CHECK (status IN ('scheduled',  'completed', 'cancelled')),
+    status TEXT NOT NULL DEFAULT 'scheduled'  CHECK (status IN ('scheduled',  'completed', 'cancelled', 'paused')),
+    pause_after_failures INT NOT NULL DEFAULT 0,
+    consecutive_failures INT NOT NULL DEFAULT 0,
 
     created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
     updated_at TIMESTAMPTZ NOT NULL DEFAULT now(),
@@ -20,15 +38,84 @@ CREATE TABLE IF NOT EXISTS tasks (
 );
 
 
+CREATE TABLE IF NOT EXISTS task_runs (
+     id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+     task_id UUID NOT NULL REFERENCES tasks(id) ON DELETE CASCADE,
+     started_at TIMESTAMPTZ NOT NULL,
+     finished_at TIMESTAMPTZ,
+     status TEXT NOT NULL DEFAULT 'running' CHECK (status IN ('running', 'succeeded', 'failed', 'interrupted')),
+     success_rule TEXT NOT NULL,
+     targets_total INT NOT NULL,
+     targets_succeeded INT NOT NULL DEFAULT 0,
+     scheduled_at TIMESTAMPTZ,
+     run_number INT NOT NULL DEFAULT 0,
+     created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+
 CREATE TABLE IF NOT EXISTS task_results (
      id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
      task_id UUID NOT NULL REFERENCES tasks(id) ON DELETE CASCADE,
+     run_id UUID REFERENCES task_runs(id) ON DELETE CASCADE,
+     target_index INT NOT NULL DEFAULT 0,
+     target_url TEXT NOT NULL DEFAULT '',
+     attempt INT NOT NULL DEFAULT 1,
      run_at TIMESTAMPTZ NOT NULL,
      status_code INT NOT NULL,
      success BOOLEAN NOT NULL,
      response_headers JSONB,
      response_body JSONB,
+     body_encoding TEXT CHECK (body_encoding IN ('json', 'text', 'base64')),
+     body_truncated BOOLEAN NOT NULL DEFAULT false,
      error_message TEXT,
+     error_class TEXT,
+     idempotency_key TEXT,
+     exit_code INT,
+     stderr TEXT,
      duration_ms INT NOT NULL,
      created_at TIMESTAMPTZ NOT NULL DEFAULT now()
 );
+
+
+CREATE TABLE IF NOT EXISTS dead_letters (
+     id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+     task_id UUID NOT NULL REFERENCES tasks(id) ON DELETE CASCADE,
+     run_id UUID REFERENCES task_runs(id) ON DELETE SET NULL,
+     target_index INT NOT NULL DEFAULT 0,
+     request JSONB NOT NULL,
+     status_code INT NOT NULL,
+     error_message TEXT,
+     error_class TEXT,
+     attempts INT NOT NULL,
+     status TEXT NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'requeued')),
+     created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+     updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+
+CREATE TABLE IF NOT EXISTS descriptor_sets (
+     id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+     name TEXT NOT NULL UNIQUE,
+     data BYTEA NOT NULL,
+     services TEXT[] NOT NULL,
+     created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+     updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+
+CREATE TABLE IF NOT EXISTS secrets (
+     id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+     name TEXT NOT NULL UNIQUE,
+     value BYTEA NOT NULL,
+     created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+     updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+
+CREATE TABLE IF NOT EXISTS tls_profiles (
+     id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+     name TEXT NOT NULL UNIQUE,
+     config JSONB NOT NULL,
+     created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+     updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
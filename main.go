@@ -2,14 +2,25 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
+	"net/http"
+	"net/mail"
 	"os"
+	"os/signal"
 	"scheduler/application/api"
+	"scheduler/clock"
 	"scheduler/database"
 	_ "scheduler/docs"
 	"scheduler/scheduler"
+	"scheduler/secrets"
 	"scheduler/workers"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+	_ "time/tzdata"
 
 	"github.com/jackc/pgx/v5/pgxpool"
 )
@@ -35,6 +46,225 @@ func ConnectDB() (*database.Queries, error) {
 	return db, nil
 }
 
+func LoadWorkerConfig() (workers.Config, error) {
+	cfg := workers.DefaultConfig()
+
+	if v := os.Getenv("TASK_TIMEOUT"); v != "" {
+		timeout, err := time.ParseDuration(v)
+		if err != nil || timeout <= 0 {
+			return cfg, fmt.Errorf("invalid TASK_TIMEOUT: %q", v)
+		}
+		cfg.DefaultTimeout = timeout
+	}
+
+	if v := os.Getenv("MAX_RETRY_AFTER"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil || d < 0 {
+			return cfg, fmt.Errorf("invalid MAX_RETRY_AFTER: %q", v)
+		}
+		cfg.MaxRetryAfter = d
+	}
+
+	for name, dst := range map[string]*int{
+		"WORKERS_MIN": &cfg.MinWorkers,
+		"WORKERS_MAX": &cfg.MaxWorkers,
+	} {
+		if v := os.Getenv(name); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil || n < 1 {
+				return cfg, fmt.Errorf("invalid %s: %q", name, v)
+			}
+			*dst = n
+		}
+	}
+	if cfg.MaxWorkers < cfg.MinWorkers {
+		if os.Getenv("WORKERS_MAX") != "" {
+			return cfg, fmt.Errorf("WORKERS_MAX (%d) is below WORKERS_MIN (%d)", cfg.MaxWorkers, cfg.MinWorkers)
+		}
+		cfg.MaxWorkers = cfg.MinWorkers
+	}
+
+	// A scale interval of 0 turns autoscaling off.
+	for name, dst := range map[string]*time.Duration{
+		"WORKERS_SCALE_INTERVAL":  &cfg.ScaleInterval,
+		"WORKERS_SCALE_UP_WAIT":   &cfg.ScaleUpWait,
+		"WORKERS_SCALE_DOWN_IDLE": &cfg.ScaleDownIdle,
+	} {
+		if v := os.Getenv(name); v != "" {
+			d, err := time.ParseDuration(v)
+			if err != nil || d < 0 {
+				return cfg, fmt.Errorf("invalid %s: %q", name, v)
+			}
+			*dst = d
+		}
+	}
+
+	if v := os.Getenv("BREAKER_FAILURE_THRESHOLD"); v != "" {
+		threshold, err := strconv.Atoi(v)
+		if err != nil || threshold < 0 {
+			return cfg, fmt.Errorf("invalid BREAKER_FAILURE_THRESHOLD: %q", v)
+		}
+		cfg.Breaker.FailureThreshold = threshold
+	}
+
+	if v := os.Getenv("BREAKER_OPEN_DURATION"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil || d <= 0 {
+			return cfg, fmt.Errorf("invalid BREAKER_OPEN_DURATION: %q", v)
+		}
+		cfg.Breaker.OpenFor = d
+	}
+
+	// IDEMPOTENCY_HEADER=none stops the key from being sent.
+	if v := os.Getenv("IDEMPOTENCY_HEADER"); v != "" {
+		if v == "none" {
+			v = ""
+		} else if strings.ContainsAny(v, " \t:\r\n") {
+			return cfg, fmt.Errorf("invalid IDEMPOTENCY_HEADER: %q", v)
+		}
+		cfg.IdempotencyHeader = v
+	}
+
+	if v := os.Getenv("MAX_RESPONSE_BODY_BYTES"); v != "" {
+		maxBytes, err := strconv.ParseInt(v, 10, 64)
+		if err != nil || maxBytes <= 0 {
+			return cfg, fmt.Errorf("invalid MAX_RESPONSE_BODY_BYTES: %q", v)
+		}
+		cfg.MaxBodyBytes = maxBytes
+	}
+
+	for name, dst := range map[string]*int{
+		"HTTP_MAX_IDLE_CONNS":          &cfg.Transport.MaxIdleConns,
+		"HTTP_MAX_IDLE_CONNS_PER_HOST": &cfg.Transport.MaxIdleConnsPerHost,
+		"HTTP_MAX_CONNS_PER_HOST":      &cfg.Transport.MaxConnsPerHost,
+	} {
+		if v := os.Getenv(name); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil || n < 0 {
+				return cfg, fmt.Errorf("invalid %s: %q", name, v)
+			}
+			*dst = n
+		}
+	}
+
+	if v := os.Getenv("HTTP_IDLE_CONN_TIMEOUT"); v != "" {
+		timeout, err := time.ParseDuration(v)
+		if err != nil {
+			return cfg, fmt.Errorf("invalid HTTP_IDLE_CONN_TIMEOUT: %w", err)
+		}
+		cfg.Transport.IdleConnTimeout = timeout
+	}
+
+	if v := os.Getenv("HTTP_DISABLE_HTTP2"); v != "" {
+		disable, err := strconv.ParseBool(v)
+		if err != nil {
+			return cfg, fmt.Errorf("invalid HTTP_DISABLE_HTTP2: %q", v)
+		}
+		cfg.Transport.DisableHTTP2 = disable
+	}
+
+	// HTTP_PROXY and friends already apply; this sets a proxy for the
+	// scheduler's actions only.
+	if v := os.Getenv("ACTION_PROXY"); v != "" {
+		if err := workers.CheckProxy(v); err != nil {
+			return cfg, fmt.Errorf("invalid ACTION_PROXY: %w", err)
+		}
+		cfg.Transport.Proxy = v
+	}
+
+	if v := os.Getenv("SECRETS_MASTER_KEY"); v != "" {
+		key, err := secrets.ParseKey(v)
+		if err != nil {
+			return cfg, fmt.Errorf("invalid SECRETS_MASTER_KEY: %w", err)
+		}
+		if cfg.Secrets, err = secrets.NewBox(key); err != nil {
+			return cfg, fmt.Errorf("invalid SECRETS_MASTER_KEY: %w", err)
+		}
+	}
+
+	return cfg, nil
+}
+
+// LoadCommandPolicy reads the executables command actions may run from
+// COMMAND_ALLOWLIST and the users they may run as from COMMAND_ALLOWED_USERS,
+// both comma separated.
+func LoadCommandPolicy() workers.CommandPolicy {
+	return workers.CommandPolicy{
+		AllowedPaths: splitList(os.Getenv("COMMAND_ALLOWLIST")),
+		AllowedUsers: splitList(os.Getenv("COMMAND_ALLOWED_USERS")),
+	}
+}
+
+// LoadSQLConnections opens a pool for every SQL_CONN_<NAME> variable. Sql
+// actions refer to them by lower-cased name.
+func LoadSQLConnections(ctx context.Context) (map[string]*pgxpool.Pool, error) {
+	conns := make(map[string]*pgxpool.Pool)
+	for _, kv := range os.Environ() {
+		key, dsn, _ := strings.Cut(kv, "=")
+		name, ok := strings.CutPrefix(key, "SQL_CONN_")
+		if !ok || name == "" {
+			continue
+		}
+
+		pool, err := pgxpool.New(ctx, dsn)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s: %w", key, err)
+		}
+		conns[strings.ToLower(name)] = pool
+	}
+	return conns, nil
+}
+
+// LoadSMTPConfig reads the relay for email actions from SMTP_ADDR, with
+// optional SMTP_USERNAME, SMTP_PASSWORD and a default sender in SMTP_FROM.
+func LoadSMTPConfig() (workers.SMTPConfig, error) {
+	cfg := workers.SMTPConfig{
+		Addr:     os.Getenv("SMTP_ADDR"),
+		Username: os.Getenv("SMTP_USERNAME"),
+		Password: os.Getenv("SMTP_PASSWORD"),
+		From:     os.Getenv("SMTP_FROM"),
+	}
+	if cfg.From != "" {
+		if _, err := mail.ParseAddress(cfg.From); err != nil {
+			return cfg, fmt.Errorf("invalid SMTP_FROM: %w", err)
+		}
+	}
+	return cfg, nil
+}
+
+// LoadBrokerConfig reads the brokers publish actions go to from NATS_URL and
+// AMQP_URL.
+func LoadBrokerConfig() workers.BrokerConfig {
+	return workers.BrokerConfig{
+		NATSURL: os.Getenv("NATS_URL"),
+		AMQPURL: os.Getenv("AMQP_URL"),
+	}
+}
+
+// LoadGracePeriod reads how long running tasks get to finish on shutdown from
+// SHUTDOWN_GRACE_PERIOD, 30s by default.
+func LoadGracePeriod() (time.Duration, error) {
+	v := os.Getenv("SHUTDOWN_GRACE_PERIOD")
+	if v == "" {
+		return 30 * time.Second, nil
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil || d < 0 {
+		return 0, fmt.Errorf("invalid SHUTDOWN_GRACE_PERIOD: %q", v)
+	}
+	return d, nil
+}
+
+func splitList(v string) []string {
+	var items []string
+	for _, item := range strings.Split(v, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
 func main() {
 
 	ctx := context.Background()
@@ -43,26 +273,98 @@ func main() {
 		log.Fatalf("Failed to connect to database %v", err)
 	}
 
+	clk := clock.New()
 	taskChan := make(chan database.Task, 100)
 
-	schedulerEngine := scheduler.NewScheduler(db, taskChan)
+	schedulerEngine := scheduler.NewScheduler(db, clk, taskChan)
 	schedulerCtx, schedulerCancel := context.WithCancel(ctx)
-	go schedulerEngine.StartScheduler(schedulerCtx)
+	schedulerDone := make(chan struct{})
+	go func() {
+		schedulerEngine.StartScheduler(schedulerCtx)
+		close(schedulerDone)
+	}()
+
+	workerCfg, err := LoadWorkerConfig()
+	if err != nil {
+		log.Fatalf("Failed to load worker config: %v", err)
+	}
+
+	gracePeriod, err := LoadGracePeriod()
+	if err != nil {
+		log.Fatalf("Failed to load shutdown config: %v", err)
+	}
+
+	workerPool := workers.NewWorkerPool(db, clk, taskChan, workerCfg)
+	if policy := LoadCommandPolicy(); len(policy.AllowedPaths) > 0 {
+		workerPool.Executors().Register("command", workers.NewCommandExecutor(policy))
+	}
+
+	sqlConns, err := LoadSQLConnections(ctx)
+	if err != nil {
+		log.Fatalf("Failed to load SQL connections: %v", err)
+	}
+	if len(sqlConns) > 0 {
+		workerPool.Executors().Register("sql", workers.NewSQLExecutor(sqlConns))
+	}
 
-	workerPool := workers.NewWorkerPool(db, taskChan, 5)
-	workerCtx, workerCancel := context.WithCancel(ctx)
-	workerPool.Start(workerCtx)
+	workerPool.Executors().Register("grpc", workers.NewGRPCExecutor(db))
 
-	server := api.NewServer(db)
+	smtpCfg, err := LoadSMTPConfig()
+	if err != nil {
+		log.Fatalf("Failed to load SMTP config: %v", err)
+	}
+	if smtpCfg.Addr != "" {
+		workerPool.Executors().Register("email", workers.NewEmailExecutor(smtpCfg, clk))
+	}
+
+	var publisher *workers.PublishExecutor
+	if brokerCfg := LoadBrokerConfig(); brokerCfg.NATSURL != "" || brokerCfg.AMQPURL != "" {
+		publisher = workers.NewPublishExecutor(brokerCfg)
+		workerPool.Executors().Register("publish", publisher)
+	}
+	workerPool.Start(ctx)
+
+	server := api.NewServer(db, clk, workerPool)
 	server.SetRoutes()
 
+	httpServer := &http.Server{Addr: ":8080", Handler: server.Router}
+	go func() {
+		if err := httpServer.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Fatalf("Failed to start server: %v", err)
+		}
+	}()
 	fmt.Println("Server is running on http://localhost:8080")
-	if err := server.Router.Run(":8080"); err != nil {
-		log.Fatalf("Failed to start server: %v", err)
-	}
 
+	signalCtx, stopSignals := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+	<-signalCtx.Done()
+	stopSignals()
+	log.Printf("Shutting down, waiting up to %v for running tasks", gracePeriod)
+
+	shutdownCtx, cancelShutdown := context.WithTimeout(ctx, gracePeriod)
+	defer cancelShutdown()
+
+	// Stop queueing first so nothing new reaches the workers, then stop
+	// the API and drain the workers side by side.
 	schedulerCancel()
+	<-schedulerDone
+
+	apiDone := make(chan struct{})
+	go func() {
+		if err := httpServer.Shutdown(shutdownCtx); err != nil {
+			log.Printf("Failed to shut down API server cleanly: %v", err)
+		}
+		close(apiDone)
+	}()
 
-	workerCancel()
-	workerPool.Stop()
+	if err := workerPool.Shutdown(shutdownCtx); err != nil {
+		log.Printf("Running tasks were interrupted: %v", err)
+	}
+	<-apiDone
+
+	for _, conn := range sqlConns {
+		conn.Close()
+	}
+	if publisher != nil {
+		publisher.Close()
+	}
 }
@@ -0,0 +1,170 @@
+package scheduler
+
+import (
+	"errors"
+	"scheduler/database"
+	"sort"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const (
+	maxForecastBuckets     = 50000
+	maxForecastOccurrences = 100000
+	defaultRunDuration     = time.Second
+)
+
+type ForecastOptions struct {
+	From    time.Time
+	To      time.Time
+	Bucket  time.Duration
+	Workers int
+	Peaks   int
+}
+
+type Forecast struct {
+	TotalRuns       int
+	PeakConcurrency int
+	Buckets         []ForecastBucket
+	Peaks           []ForecastBucket
+}
+
+type ForecastBucket struct {
+	Start           time.Time
+	Runs            int
+	PeakConcurrency int
+	Queued          int
+	Tasks           []ForecastTaskRuns
+}
+
+type ForecastTaskRuns struct {
+	TaskID pgtype.UUID
+	Name   string
+	Runs   int
+}
+
+type forecastEvent struct {
+	at    time.Time
+	delta int
+}
+
+// Simulate expands the triggers of the given tasks over the forecast window
+// and estimates how many runs are in flight at once, using each task's
+// historical duration (or defaultRunDuration when it has never run). Runs
+// beyond the worker count in a bucket are reported as queued.
+func Simulate(tasks []database.Task, durations map[pgtype.UUID]time.Duration, opts ForecastOptions) (Forecast, error) {
+	if !opts.To.After(opts.From) {
+		return Forecast{}, errors.New("to must be after from")
+	}
+	if opts.Bucket <= 0 {
+		return Forecast{}, errors.New("bucket must be positive")
+	}
+	count := int(opts.To.Sub(opts.From) / opts.Bucket)
+	if opts.To.Sub(opts.From)%opts.Bucket != 0 {
+		count++
+	}
+	if count > maxForecastBuckets {
+		return Forecast{}, errors.New("too many buckets, use a larger bucket or a shorter range")
+	}
+
+	buckets := make([]ForecastBucket, count)
+	perTask := make([]map[int]int, count)
+	for i := range buckets {
+		buckets[i].Start = opts.From.Add(time.Duration(i) * opts.Bucket)
+	}
+
+	var events []forecastEvent
+	for i, task := range tasks {
+		if !task.NextRun.Valid {
+			continue
+		}
+		from := opts.From
+		if task.NextRun.Time.After(from) {
+			from = task.NextRun.Time
+		}
+
+		occurrences, err := TriggerFromTask(task).Occurrences(from, opts.To, maxForecastOccurrences)
+		if err != nil {
+			continue
+		}
+
+		duration, ok := durations[task.ID]
+		if !ok || duration <= 0 {
+			duration = defaultRunDuration
+		}
+
+		for _, at := range occurrences {
+			idx := int(at.Sub(opts.From) / opts.Bucket)
+			buckets[idx].Runs++
+			if perTask[idx] == nil {
+				perTask[idx] = make(map[int]int)
+			}
+			perTask[idx][i]++
+			events = append(events,
+				forecastEvent{at: at, delta: 1},
+				forecastEvent{at: at.Add(duration), delta: -1},
+			)
+		}
+	}
+
+	sort.Slice(events, func(a, b int) bool {
+		if events[a].at.Equal(events[b].at) {
+			return events[a].delta < events[b].delta
+		}
+		return events[a].at.Before(events[b].at)
+	})
+
+	forecast := Forecast{}
+	inFlight := 0
+	for _, event := range events {
+		inFlight += event.delta
+		if event.delta < 0 {
+			continue
+		}
+		forecast.TotalRuns++
+		idx := int(event.at.Sub(opts.From) / opts.Bucket)
+		if inFlight > buckets[idx].PeakConcurrency {
+			buckets[idx].PeakConcurrency = inFlight
+		}
+		if inFlight > forecast.PeakConcurrency {
+			forecast.PeakConcurrency = inFlight
+		}
+	}
+
+	for idx, counts := range perTask {
+		for i, runs := range counts {
+			buckets[idx].Tasks = append(buckets[idx].Tasks, ForecastTaskRuns{
+				TaskID: tasks[i].ID,
+				Name:   tasks[i].Name,
+				Runs:   runs,
+			})
+		}
+		sort.Slice(buckets[idx].Tasks, func(a, b int) bool {
+			return buckets[idx].Tasks[a].Runs > buckets[idx].Tasks[b].Runs
+		})
+		if opts.Workers > 0 && buckets[idx].PeakConcurrency > opts.Workers {
+			buckets[idx].Queued = buckets[idx].PeakConcurrency - opts.Workers
+		}
+	}
+	forecast.Buckets = buckets
+
+	var peaks []ForecastBucket
+	for _, bucket := range buckets {
+		if bucket.Runs > 0 {
+			peaks = append(peaks, bucket)
+		}
+	}
+	sort.SliceStable(peaks, func(a, b int) bool {
+		if peaks[a].PeakConcurrency == peaks[b].PeakConcurrency {
+			return peaks[a].Runs > peaks[b].Runs
+		}
+		return peaks[a].PeakConcurrency > peaks[b].PeakConcurrency
+	})
+	if opts.Peaks > 0 && len(peaks) > opts.Peaks {
+		peaks = peaks[:opts.Peaks]
+	}
+	forecast.Peaks = peaks
+
+	return forecast, nil
+}
@@ -0,0 +1,86 @@
+package scheduler
+
+import (
+	"scheduler/database"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+func TestSimulate(t *testing.T) {
+	from := time.Date(2026, 3, 8, 10, 0, 0, 0, time.UTC)
+	valid := pgtype.Timestamptz{Time: from, Valid: true}
+
+	quarterly := testTask(1, "quarter hourly", "cron")
+	quarterly.TriggerCron = pgtype.Text{String: "*/15 * * * *", Valid: true}
+	quarterly.NextRun = valid
+
+	hourly := testTask(2, "hourly", "cron")
+	hourly.TriggerCron = pgtype.Text{String: "0 * * * *", Valid: true}
+	hourly.NextRun = valid
+
+	once := testTask(3, "once", "one-off")
+	once.TriggerDatetime = pgtype.Timestamptz{Time: from.Add(20 * time.Minute), Valid: true}
+	once.NextRun = once.TriggerDatetime
+
+	// Paused tasks have no next run and are left out.
+	paused := testTask(4, "paused", "cron")
+	paused.TriggerCron = pgtype.Text{String: "* * * * *", Valid: true}
+
+	durations := map[pgtype.UUID]time.Duration{hourly.ID: 20 * time.Minute}
+	forecast, err := Simulate([]database.Task{quarterly, hourly, once, paused}, durations, ForecastOptions{
+		From:    from,
+		To:      from.Add(time.Hour),
+		Bucket:  15 * time.Minute,
+		Workers: 1,
+		Peaks:   2,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if forecast.TotalRuns != 6 || forecast.PeakConcurrency != 2 {
+		t.Errorf("total runs = %d, peak = %d; want 6 and 2", forecast.TotalRuns, forecast.PeakConcurrency)
+	}
+	want := []struct{ runs, peak, queued int }{
+		// 10:00: both cron tasks start together.
+		{2, 2, 1},
+		// 10:15 overlaps the hourly run; it ends as the one-off starts at 10:20.
+		{2, 2, 1},
+		{1, 1, 0},
+		{1, 1, 0},
+	}
+	if len(forecast.Buckets) != len(want) {
+		t.Fatalf("got %d buckets, want %d", len(forecast.Buckets), len(want))
+	}
+	for i, w := range want {
+		b := forecast.Buckets[i]
+		if !b.Start.Equal(from.Add(time.Duration(i) * 15 * time.Minute)) {
+			t.Errorf("bucket %d starts at %s", i, b.Start)
+		}
+		if b.Runs != w.runs || b.PeakConcurrency != w.peak || b.Queued != w.queued {
+			t.Errorf("bucket %d = %d runs, peak %d, queued %d; want %+v", i, b.Runs, b.PeakConcurrency, b.Queued, w)
+		}
+	}
+	if tasks := forecast.Buckets[1].Tasks; len(tasks) != 2 {
+		t.Errorf("bucket 1 tasks = %+v, want the quarter hourly and one-off runs", tasks)
+	}
+
+	if len(forecast.Peaks) != 2 || !forecast.Peaks[0].Start.Equal(from) || !forecast.Peaks[1].Start.Equal(from.Add(15*time.Minute)) {
+		t.Errorf("peaks = %+v, want the first two buckets", forecast.Peaks)
+	}
+}
+
+func TestSimulateRejectsBadWindows(t *testing.T) {
+	from := time.Date(2026, 3, 8, 10, 0, 0, 0, time.UTC)
+	for name, opts := range map[string]ForecastOptions{
+		"empty window":     {From: from, To: from, Bucket: time.Minute},
+		"no bucket":        {From: from, To: from.Add(time.Hour)},
+		"too many buckets": {From: from, To: from.AddDate(1, 0, 0), Bucket: time.Second},
+	} {
+		if _, err := Simulate(nil, nil, opts); err == nil {
+			t.Errorf("%s: Simulate succeeded", name)
+		}
+	}
+}
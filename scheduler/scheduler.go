@@ -3,20 +3,29 @@ package scheduler
 import (
 	"context"
 	"log"
+	"scheduler/clock"
 	"scheduler/database"
 	"time"
 
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
+// store is the part of the database the scheduler uses.
+type store interface {
+	GetTasksToRun(ctx context.Context, nextRun pgtype.Timestamptz) ([]database.Task, error)
+	SetTaskNextRun(ctx context.Context, arg database.SetTaskNextRunParams) (database.Task, error)
+	UpdateTaskStatus(ctx context.Context, arg database.UpdateTaskStatusParams) (database.Task, error)
+}
+
 type Scheduler struct {
-	db       *database.Queries
+	db       store
+	clock    clock.Clock
 	interval time.Duration
 	taskChan chan<- database.Task
 }
 
 func (s *Scheduler) StartScheduler(ctx context.Context) {
-	ticker := time.NewTicker(s.interval)
+	ticker := s.clock.NewTicker(s.interval)
 	defer ticker.Stop()
 
 	for {
@@ -24,15 +33,16 @@ func (s *Scheduler) StartScheduler(ctx context.Context) {
 		case <-ctx.Done():
 			return
 
-		case <-ticker.C:
+		case <-ticker.C():
 			s.pollAndQueueTasks(ctx)
 		}
 	}
 }
 
-func NewScheduler(db *database.Queries, taskChan chan<- database.Task) *Scheduler {
+func NewScheduler(db *database.Queries, clk clock.Clock, taskChan chan<- database.Task) *Scheduler {
 	return &Scheduler{
 		db:       db,
+		clock:    clk,
 		interval: 30 * time.Second,
 		taskChan: taskChan,
 	}
@@ -50,27 +60,57 @@ func (s *Scheduler) pollAndQueueTasks(ctx context.Context) {
 		return
 	}
 
-	log.Printf("Found scheduled ready tasks", len(readyTasks))
+	log.Printf("Found %d scheduled ready tasks", len(readyTasks))
 
 	for _, task := range readyTasks {
-		s.queueTask(task)
-
+		if s.queueTask(task) {
+			s.advanceTask(ctx, task)
+		}
 	}
 }
 
 func (s *Scheduler) findReadyTasks(ctx context.Context) ([]database.Task, error) {
 	now := pgtype.Timestamptz{
-		Time:  time.Now().UTC(),
+		Time:  s.clock.Now(),
 		Valid: true,
 	}
 	return s.db.GetTasksToRun(ctx, now)
 }
 
-func (s *Scheduler) queueTask(task database.Task) {
+func (s *Scheduler) queueTask(task database.Task) bool {
 	select {
 	case s.taskChan <- task:
 		log.Printf("Queued task: %s", task.Name)
+		return true
 	default:
 		log.Printf("Task queue full, dropping task: %s", task.Name)
+		return false
+	}
+}
+
+// advanceTask moves next_run past the occurrence that was just queued so the
+// task isn't picked up again on the next poll. Missed occurrences are skipped.
+// A task whose next run can't be computed is paused with next_run unchanged,
+// so it neither fires on every poll nor loses its schedule.
+func (s *Scheduler) advanceTask(ctx context.Context, task database.Task) {
+	next, ok, err := TriggerFromTask(task).Next(s.clock.Now())
+	if err != nil {
+		log.Printf("Failed to compute next run for task %s, pausing it: %v", task.Name, err)
+		_, err = s.db.UpdateTaskStatus(ctx, database.UpdateTaskStatusParams{
+			ID:     task.ID,
+			Status: "paused",
+		})
+		if err != nil {
+			log.Printf("Failed to pause task %s: %v", task.Name, err)
+		}
+		return
+	}
+
+	_, err = s.db.SetTaskNextRun(ctx, database.SetTaskNextRunParams{
+		ID:      task.ID,
+		NextRun: pgtype.Timestamptz{Time: next, Valid: ok},
+	})
+	if err != nil {
+		log.Printf("Failed to update next run for task %s: %v", task.Name, err)
 	}
 }
@@ -0,0 +1,223 @@
+package scheduler
+
+import (
+	"context"
+	"scheduler/clock"
+	"scheduler/database"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+type fakeStore struct {
+	mu       sync.Mutex
+	ready    []database.Task
+	polledAt []time.Time
+	nextRuns map[[16]byte]pgtype.Timestamptz
+	statuses map[[16]byte]string
+}
+
+func newFakeStore(ready ...database.Task) *fakeStore {
+	return &fakeStore{
+		ready:    ready,
+		nextRuns: make(map[[16]byte]pgtype.Timestamptz),
+		statuses: make(map[[16]byte]string),
+	}
+}
+
+func (f *fakeStore) GetTasksToRun(ctx context.Context, nextRun pgtype.Timestamptz) ([]database.Task, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.polledAt = append(f.polledAt, nextRun.Time)
+	return f.ready, nil
+}
+
+func (f *fakeStore) SetTaskNextRun(ctx context.Context, arg database.SetTaskNextRunParams) (database.Task, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.nextRuns[arg.ID.Bytes] = arg.NextRun
+	return database.Task{ID: arg.ID, NextRun: arg.NextRun}, nil
+}
+
+func (f *fakeStore) UpdateTaskStatus(ctx context.Context, arg database.UpdateTaskStatusParams) (database.Task, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.statuses[arg.ID.Bytes] = arg.Status
+	return database.Task{ID: arg.ID, Status: arg.Status}, nil
+}
+
+func (f *fakeStore) nextRun(t *testing.T, task database.Task) pgtype.Timestamptz {
+	t.Helper()
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	next, ok := f.nextRuns[task.ID.Bytes]
+	if !ok {
+		t.Fatalf("next_run for %s was not written", task.Name)
+	}
+	return next
+}
+
+func testTask(id byte, name, triggerType string) database.Task {
+	return database.Task{
+		ID:          pgtype.UUID{Bytes: [16]byte{id}, Valid: true},
+		Name:        name,
+		TriggerType: triggerType,
+	}
+}
+
+func newTestScheduler(store store, clk clock.Clock, queue int) (*Scheduler, chan database.Task) {
+	taskChan := make(chan database.Task, queue)
+	return &Scheduler{db: store, clock: clk, interval: 30 * time.Second, taskChan: taskChan}, taskChan
+}
+
+func TestAdvanceTask(t *testing.T) {
+	start := time.Date(2026, 3, 8, 10, 17, 30, 0, time.UTC)
+
+	cronTask := testTask(1, "every five minutes", "cron")
+	cronTask.TriggerCron = pgtype.Text{String: "*/5 * * * *", Valid: true}
+
+	rruleTask := testTask(2, "twice", "rrule")
+	rruleTask.TriggerRrule = pgtype.Text{String: "DTSTART:20260308T100000Z\nRRULE:FREQ=MINUTELY;INTERVAL=10;COUNT=2", Valid: true}
+
+	oneOff := testTask(3, "once", "one-off")
+	oneOff.TriggerDatetime = pgtype.Timestamptz{Time: start.Add(-time.Minute), Valid: true}
+
+	tests := []struct {
+		name string
+		task database.Task
+		want pgtype.Timestamptz
+	}{
+		{
+			// next_run was 10:00; 10:05, 10:10 and 10:15 were missed.
+			name: "misfired cron skips missed occurrences",
+			task: cronTask,
+			want: pgtype.Timestamptz{Time: time.Date(2026, 3, 8, 10, 20, 0, 0, time.UTC), Valid: true},
+		},
+		{
+			name: "exhausted rrule clears next_run",
+			task: rruleTask,
+		},
+		{
+			name: "fired one-off clears next_run",
+			task: oneOff,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := newFakeStore()
+			s, _ := newTestScheduler(store, clock.NewFake(start), 1)
+			s.advanceTask(context.Background(), tt.task)
+
+			got := store.nextRun(t, tt.task)
+			if got.Valid != tt.want.Valid || !got.Time.Equal(tt.want.Time) {
+				t.Errorf("next_run = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAdvanceTaskPausesOnError(t *testing.T) {
+	task := testTask(1, "broken", "rrule")
+	task.TriggerRrule = pgtype.Text{String: "RRULE:FREQ=DAILY", Valid: true}
+
+	store := newFakeStore()
+	s, _ := newTestScheduler(store, clock.NewFake(time.Date(2026, 3, 8, 10, 0, 0, 0, time.UTC)), 1)
+	s.advanceTask(context.Background(), task)
+
+	if _, ok := store.nextRuns[task.ID.Bytes]; ok {
+		t.Error("next_run was written for a task whose next run can't be computed")
+	}
+	if got := store.statuses[task.ID.Bytes]; got != "paused" {
+		t.Errorf("status = %q, want paused", got)
+	}
+}
+
+func TestAdvanceTaskFollowsClock(t *testing.T) {
+	clk := clock.NewFake(time.Date(2026, 12, 31, 23, 59, 30, 0, time.UTC))
+	task := testTask(1, "midnight", "cron")
+	task.TriggerCron = pgtype.Text{String: "0 0 * * *", Valid: true}
+
+	store := newFakeStore()
+	s, _ := newTestScheduler(store, clk, 1)
+
+	s.advanceTask(context.Background(), task)
+	if got, want := store.nextRun(t, task).Time, time.Date(2027, 1, 1, 0, 0, 0, 0, time.UTC); !got.Equal(want) {
+		t.Errorf("next_run = %s, want %s", got, want)
+	}
+
+	// A run that finishes just after midnight moves on to the next day.
+	clk.Advance(time.Minute)
+	s.advanceTask(context.Background(), task)
+	if got, want := store.nextRun(t, task).Time, time.Date(2027, 1, 2, 0, 0, 0, 0, time.UTC); !got.Equal(want) {
+		t.Errorf("next_run = %s, want %s", got, want)
+	}
+}
+
+func TestPollLeavesDroppedTasks(t *testing.T) {
+	now := time.Date(2026, 3, 8, 10, 0, 0, 0, time.UTC)
+	first := testTask(1, "first", "cron")
+	first.TriggerCron = pgtype.Text{String: "* * * * *", Valid: true}
+	second := testTask(2, "second", "cron")
+	second.TriggerCron = pgtype.Text{String: "* * * * *", Valid: true}
+
+	store := newFakeStore(first, second)
+	s, taskChan := newTestScheduler(store, clock.NewFake(now), 1)
+	s.pollAndQueueTasks(context.Background())
+
+	if got := <-taskChan; got.Name != "first" {
+		t.Errorf("queued %s, want first", got.Name)
+	}
+	if len(store.polledAt) != 1 || !store.polledAt[0].Equal(now) {
+		t.Errorf("polled at %v, want %s", store.polledAt, now)
+	}
+	store.nextRun(t, first)
+	if _, ok := store.nextRuns[second.ID.Bytes]; ok {
+		t.Error("next_run of a dropped task was advanced")
+	}
+}
+
+func TestStartSchedulerPollsOnTicks(t *testing.T) {
+	clk := clock.NewFake(time.Date(2026, 3, 8, 10, 0, 0, 0, time.UTC))
+	task := testTask(1, "minutely", "cron")
+	task.TriggerCron = pgtype.Text{String: "* * * * *", Valid: true}
+
+	store := newFakeStore(task)
+	s, taskChan := newTestScheduler(store, clk, 1)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		s.StartScheduler(ctx)
+		close(done)
+	}()
+	defer func() {
+		cancel()
+		<-done
+	}()
+
+	// The ticker is created on the scheduler's goroutine, so keep advancing
+	// until a poll happens.
+	deadline := time.After(5 * time.Second)
+	for {
+		clk.Advance(s.interval)
+		select {
+		case got := <-taskChan:
+			if got.Name != task.Name {
+				t.Fatalf("queued %s, want %s", got.Name, task.Name)
+			}
+			store.mu.Lock()
+			polledAt := store.polledAt[len(store.polledAt)-1]
+			store.mu.Unlock()
+			if polledAt.Before(time.Date(2026, 3, 8, 10, 0, 30, 0, time.UTC)) {
+				t.Errorf("polled at %s, before the first tick", polledAt)
+			}
+			return
+		case <-time.After(10 * time.Millisecond):
+		case <-deadline:
+			t.Fatal("scheduler never polled")
+		}
+	}
+}
@@ -0,0 +1,137 @@
+package scheduler
+
+import (
+	"errors"
+	"fmt"
+	"scheduler/database"
+	"strings"
+	"time"
+
+	"github.com/robfig/cron/v3"
+	"github.com/teambition/rrule-go"
+)
+
+type Trigger struct {
+	Type     string
+	DateTime time.Time
+	Cron     string
+	RRule    string
+	Timezone string
+}
+
+func TriggerFromTask(task database.Task) Trigger {
+	return Trigger{
+		Type:     task.TriggerType,
+		DateTime: task.TriggerDatetime.Time,
+		Cron:     task.TriggerCron.String,
+		RRule:    task.TriggerRrule.String,
+		Timezone: task.TriggerTimezone.String,
+	}
+}
+
+// Next returns the first occurrence strictly after the given time. The
+// boolean is false when the trigger will never fire again.
+func (t Trigger) Next(after time.Time) (time.Time, bool, error) {
+	switch t.Type {
+	case "one-off":
+		if t.DateTime.After(after) {
+			return t.DateTime, true, nil
+		}
+		return time.Time{}, false, nil
+
+	case "cron":
+		schedule, err := cron.ParseStandard(t.Cron)
+		if err != nil {
+			return time.Time{}, false, err
+		}
+		return schedule.Next(after).UTC(), true, nil
+
+	case "rrule":
+		set, err := parseRRule(t.RRule, t.Timezone)
+		if err != nil {
+			return time.Time{}, false, err
+		}
+		next := set.After(after, false)
+		if next.IsZero() {
+			return time.Time{}, false, nil
+		}
+		return next.UTC(), true, nil
+	}
+
+	return time.Time{}, false, fmt.Errorf("unknown trigger type %q", t.Type)
+}
+
+// Occurrences lists every firing in [from, to), stopping after limit entries.
+func (t Trigger) Occurrences(from time.Time, to time.Time, limit int) ([]time.Time, error) {
+	var out []time.Time
+
+	switch t.Type {
+	case "one-off":
+		if !t.DateTime.Before(from) && t.DateTime.Before(to) {
+			out = append(out, t.DateTime)
+		}
+
+	case "cron":
+		schedule, err := cron.ParseStandard(t.Cron)
+		if err != nil {
+			return nil, err
+		}
+		for next := schedule.Next(from.Add(-time.Second)); next.Before(to) && len(out) < limit; next = schedule.Next(next) {
+			if !next.Before(from) {
+				out = append(out, next.UTC())
+			}
+		}
+
+	case "rrule":
+		set, err := parseRRule(t.RRule, t.Timezone)
+		if err != nil {
+			return nil, err
+		}
+		next := set.Iterator()
+		for len(out) < limit {
+			occurrence, ok := next()
+			if !ok || !occurrence.Before(to) {
+				break
+			}
+			if !occurrence.Before(from) {
+				out = append(out, occurrence.UTC())
+			}
+		}
+
+	default:
+		return nil, fmt.Errorf("unknown trigger type %q", t.Type)
+	}
+
+	return out, nil
+}
+
+func parseRRule(expr string, timezone string) (*rrule.Set, error) {
+	loc := time.UTC
+	if timezone != "" {
+		var err error
+		loc, err = time.LoadLocation(timezone)
+		if err != nil {
+			return nil, fmt.Errorf("invalid timezone: %w", err)
+		}
+	}
+
+	var lines []string
+	for _, line := range strings.Split(expr, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			lines = append(lines, line)
+		}
+	}
+
+	set, err := rrule.StrSliceToRRuleSetInLoc(lines, loc)
+	if err != nil {
+		return nil, err
+	}
+	if set.GetRRule() == nil {
+		return nil, errors.New("RRULE is required")
+	}
+	if set.GetDTStart().IsZero() {
+		return nil, errors.New("DTSTART is required")
+	}
+
+	return set, nil
+}
@@ -0,0 +1,259 @@
+package scheduler
+
+import (
+	"testing"
+	"time"
+)
+
+func mustLoad(t *testing.T, name string) *time.Location {
+	t.Helper()
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		t.Skipf("timezone %s not available: %v", name, err)
+	}
+	return loc
+}
+
+func TestTriggerNext(t *testing.T) {
+	ny := mustLoad(t, "America/New_York")
+
+	tests := []struct {
+		name    string
+		trigger Trigger
+		after   time.Time
+		want    []time.Time
+		// done is set when the trigger never fires after want.
+		done bool
+	}{
+		{
+			name:    "cron rolls over the hour and day",
+			trigger: Trigger{Type: "cron", Cron: "*/15 * * * *"},
+			after:   time.Date(2026, 3, 31, 23, 50, 0, 0, time.UTC),
+			want: []time.Time{
+				time.Date(2026, 4, 1, 0, 0, 0, 0, time.UTC),
+				time.Date(2026, 4, 1, 0, 15, 0, 0, time.UTC),
+			},
+		},
+		{
+			name:    "cron rolls over the year",
+			trigger: Trigger{Type: "cron", Cron: "59 23 31 12 *"},
+			after:   time.Date(2025, 12, 31, 23, 59, 0, 0, time.UTC),
+			want: []time.Time{
+				time.Date(2026, 12, 31, 23, 59, 0, 0, time.UTC),
+			},
+		},
+		{
+			name:    "cron skips months without the day",
+			trigger: Trigger{Type: "cron", Cron: "0 12 31 * *"},
+			after:   time.Date(2026, 1, 31, 12, 0, 0, 0, time.UTC),
+			want: []time.Time{
+				time.Date(2026, 3, 31, 12, 0, 0, 0, time.UTC),
+				time.Date(2026, 5, 31, 12, 0, 0, 0, time.UTC),
+			},
+		},
+		{
+			// 02:30 doesn't exist on 8 March in New York, so that day is skipped.
+			name:    "cron in a gap at spring forward",
+			trigger: Trigger{Type: "cron", Cron: "CRON_TZ=America/New_York 30 2 * * *"},
+			after:   time.Date(2026, 3, 7, 12, 0, 0, 0, ny),
+			want: []time.Time{
+				time.Date(2026, 3, 9, 2, 30, 0, 0, ny).UTC(),
+				time.Date(2026, 3, 10, 2, 30, 0, 0, ny).UTC(),
+			},
+		},
+		{
+			// 01:30 happens twice on 1 November in New York.
+			name:    "cron in the overlap at fall back",
+			trigger: Trigger{Type: "cron", Cron: "CRON_TZ=America/New_York 30 1 * * *"},
+			after:   time.Date(2026, 10, 31, 12, 0, 0, 0, ny),
+			want: []time.Time{
+				time.Date(2026, 11, 1, 5, 30, 0, 0, time.UTC),
+				time.Date(2026, 11, 1, 6, 30, 0, 0, time.UTC),
+				time.Date(2026, 11, 2, 6, 30, 0, 0, time.UTC),
+			},
+		},
+		{
+			name: "rrule keeps local time across spring forward",
+			trigger: Trigger{
+				Type:     "rrule",
+				RRule:    "DTSTART:20260306T090000\nRRULE:FREQ=DAILY",
+				Timezone: "America/New_York",
+			},
+			after: time.Date(2026, 3, 6, 14, 0, 0, 0, time.UTC),
+			want: []time.Time{
+				time.Date(2026, 3, 7, 14, 0, 0, 0, time.UTC),
+				time.Date(2026, 3, 8, 13, 0, 0, 0, time.UTC),
+				time.Date(2026, 3, 9, 13, 0, 0, 0, time.UTC),
+			},
+		},
+		{
+			name: "rrule ends after its count",
+			trigger: Trigger{
+				Type:  "rrule",
+				RRule: "DTSTART:20260101T000000Z\nRRULE:FREQ=DAILY;COUNT=2",
+			},
+			after: time.Date(2025, 12, 31, 0, 0, 0, 0, time.UTC),
+			want: []time.Time{
+				time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC),
+				time.Date(2026, 1, 2, 0, 0, 0, 0, time.UTC),
+			},
+			done: true,
+		},
+		{
+			name:    "one-off fires once",
+			trigger: Trigger{Type: "one-off", DateTime: time.Date(2026, 6, 1, 8, 0, 0, 0, time.UTC)},
+			after:   time.Date(2026, 5, 1, 0, 0, 0, 0, time.UTC),
+			want: []time.Time{
+				time.Date(2026, 6, 1, 8, 0, 0, 0, time.UTC),
+			},
+			done: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			after := tt.after
+			for i, want := range tt.want {
+				got, ok, err := tt.trigger.Next(after)
+				if err != nil {
+					t.Fatalf("Next #%d: %v", i, err)
+				}
+				if !ok || !got.Equal(want) {
+					t.Fatalf("Next #%d after %s = %s, %v; want %s", i, after, got, ok, want)
+				}
+				if got.Location() != time.UTC {
+					t.Errorf("Next #%d returned %s, want UTC", i, got.Location())
+				}
+				after = got
+			}
+			if tt.done {
+				if got, ok, err := tt.trigger.Next(after); err != nil || ok {
+					t.Errorf("Next after the last occurrence = %s, %v, %v; want none", got, ok, err)
+				}
+			}
+		})
+	}
+}
+
+func TestTriggerNextInvalid(t *testing.T) {
+	for _, trigger := range []Trigger{
+		{Type: "cron", Cron: "not a cron"},
+		{Type: "rrule", RRule: "RRULE:FREQ=DAILY"},
+		{Type: "rrule", RRule: "DTSTART:20260101T000000\nRRULE:FREQ=DAILY", Timezone: "Nowhere/Special"},
+		{Type: "interval"},
+	} {
+		if _, _, err := trigger.Next(time.Now()); err == nil {
+			t.Errorf("Next(%+v) succeeded, want an error", trigger)
+		}
+	}
+}
+
+func TestTriggerOccurrences(t *testing.T) {
+	from := time.Date(2026, 3, 8, 5, 0, 0, 0, time.UTC)
+	to := time.Date(2026, 3, 8, 9, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		trigger Trigger
+		from    time.Time
+		to      time.Time
+		limit   int
+		want    []time.Time
+	}{
+		{
+			name:    "cron includes from and excludes to",
+			trigger: Trigger{Type: "cron", Cron: "0 * * * *"},
+			from:    from,
+			to:      to,
+			limit:   10,
+			want: []time.Time{
+				time.Date(2026, 3, 8, 5, 0, 0, 0, time.UTC),
+				time.Date(2026, 3, 8, 6, 0, 0, 0, time.UTC),
+				time.Date(2026, 3, 8, 7, 0, 0, 0, time.UTC),
+				time.Date(2026, 3, 8, 8, 0, 0, 0, time.UTC),
+			},
+		},
+		{
+			name:    "cron stops at the limit",
+			trigger: Trigger{Type: "cron", Cron: "* * * * *"},
+			from:    from,
+			to:      to,
+			limit:   3,
+			want: []time.Time{
+				time.Date(2026, 3, 8, 5, 0, 0, 0, time.UTC),
+				time.Date(2026, 3, 8, 5, 1, 0, 0, time.UTC),
+				time.Date(2026, 3, 8, 5, 2, 0, 0, time.UTC),
+			},
+		},
+		{
+			// 02:30 local is skipped as the clocks go forward.
+			name: "rrule hourly across spring forward",
+			trigger: Trigger{
+				Type:     "rrule",
+				RRule:    "DTSTART:20260306T013000\nRRULE:FREQ=HOURLY",
+				Timezone: "America/New_York",
+			},
+			from:  from,
+			to:    to,
+			limit: 10,
+			want: []time.Time{
+				time.Date(2026, 3, 8, 5, 30, 0, 0, time.UTC),
+				time.Date(2026, 3, 8, 6, 30, 0, 0, time.UTC),
+				time.Date(2026, 3, 8, 7, 30, 0, 0, time.UTC),
+				time.Date(2026, 3, 8, 8, 30, 0, 0, time.UTC),
+			},
+		},
+		{
+			name: "rrule stops at the limit",
+			trigger: Trigger{
+				Type:  "rrule",
+				RRule: "DTSTART:20260101T000000Z\nRRULE:FREQ=MINUTELY",
+			},
+			from:  from,
+			to:    to,
+			limit: 2,
+			want: []time.Time{
+				time.Date(2026, 3, 8, 5, 0, 0, 0, time.UTC),
+				time.Date(2026, 3, 8, 5, 1, 0, 0, time.UTC),
+			},
+		},
+		{
+			// Between would expand every occurrence in the window first.
+			name: "rrule stops at the limit in a wide window",
+			trigger: Trigger{
+				Type:  "rrule",
+				RRule: "DTSTART:20260308T040000Z\nRRULE:FREQ=SECONDLY",
+			},
+			from:  from,
+			to:    from.AddDate(100, 0, 0),
+			limit: 1,
+			want: []time.Time{
+				time.Date(2026, 3, 8, 5, 0, 0, 0, time.UTC),
+			},
+		},
+		{
+			name:    "one-off outside the window",
+			trigger: Trigger{Type: "one-off", DateTime: to},
+			from:    from,
+			to:      to,
+			limit:   10,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.trigger.Occurrences(tt.from, tt.to, tt.limit)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %d occurrences %v, want %v", len(got), got, tt.want)
+			}
+			for i := range got {
+				if !got[i].Equal(tt.want[i]) {
+					t.Errorf("occurrence %d = %s, want %s", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
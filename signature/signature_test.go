@@ -0,0 +1,160 @@
+package signature
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSignVerify(t *testing.T) {
+	key := []byte("k1")
+	sig := Sign(key, 1700000000, "post", "https://api.example.com/hook?x=1", []byte(`{"a":1}`))
+
+	tests := []struct {
+		name      string
+		key       []byte
+		timestamp int64
+		method    string
+		url       string
+		body      string
+		signature string
+		want      error
+	}{
+		{"valid", key, 1700000000, "POST", "https://api.example.com/hook?x=1", `{"a":1}`, sig, nil},
+		{"tampered body", key, 1700000000, "POST", "https://api.example.com/hook?x=1", `{"a":2}`, sig, ErrMismatch},
+		{"tampered url", key, 1700000000, "POST", "https://api.example.com/hook?x=2", `{"a":1}`, sig, ErrMismatch},
+		{"tampered method", key, 1700000000, "PUT", "https://api.example.com/hook?x=1", `{"a":1}`, sig, ErrMismatch},
+		{"tampered timestamp", key, 1700000001, "POST", "https://api.example.com/hook?x=1", `{"a":1}`, sig, ErrMismatch},
+		{"wrong secret", []byte("k2"), 1700000000, "POST", "https://api.example.com/hook?x=1", `{"a":1}`, sig, ErrMismatch},
+		{"missing prefix", key, 1700000000, "POST", "https://api.example.com/hook?x=1", `{"a":1}`, strings.TrimPrefix(sig, prefix), ErrMismatch},
+		{"not hex", key, 1700000000, "POST", "https://api.example.com/hook?x=1", `{"a":1}`, prefix + "zz", ErrMismatch},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Verify(tt.key, tt.timestamp, tt.method, tt.url, []byte(tt.body), tt.signature)
+			if !errors.Is(err, tt.want) {
+				t.Errorf("Verify = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func signedRequest(key []byte, ts time.Time, url, body string) *http.Request {
+	r := httptest.NewRequest(http.MethodPost, url, strings.NewReader(body))
+	timestamp := ts.Unix()
+	r.Header.Set(DefaultTimestampHeader, strconv.FormatInt(timestamp, 10))
+	r.Header.Set(DefaultSignatureHeader, Sign(key, timestamp, http.MethodPost, url, []byte(body)))
+	return r
+}
+
+func TestVerifyRequest(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	oldKey, newKey := []byte("old"), []byte("new")
+	const url = "https://api.example.com/hook/42"
+
+	tests := []struct {
+		name    string
+		request func() *http.Request
+		// verifyURL is the rendered URL the receiver checks against.
+		verifyURL string
+		verifier  Verifier
+		want      error
+	}{
+		{
+			name:     "valid",
+			request:  func() *http.Request { return signedRequest(newKey, now, url, `{"a":1}`) },
+			verifier: Verifier{Key: newKey},
+		},
+		{
+			name:      "rendered url",
+			request:   func() *http.Request { return signedRequest(newKey, now, url, `{"a":1}`) },
+			verifyURL: url,
+			verifier:  Verifier{Key: newKey},
+		},
+		{
+			name:      "unrendered url",
+			request:   func() *http.Request { return signedRequest(newKey, now, url, `{"a":1}`) },
+			verifyURL: "https://api.example.com/hook/{{.Run}}",
+			verifier:  Verifier{Key: newKey},
+			want:      ErrMismatch,
+		},
+		{
+			name: "tampered body",
+			request: func() *http.Request {
+				r := signedRequest(newKey, now, url, `{"a":1}`)
+				r.Body = io.NopCloser(strings.NewReader(`{"a":2}`))
+				return r
+			},
+			verifier: Verifier{Key: newKey},
+			want:     ErrMismatch,
+		},
+		{
+			name:     "wrong secret",
+			request:  func() *http.Request { return signedRequest([]byte("other"), now, url, `{"a":1}`) },
+			verifier: Verifier{Key: newKey},
+			want:     ErrMismatch,
+		},
+		{
+			name:     "expired",
+			request:  func() *http.Request { return signedRequest(newKey, now.Add(-6*time.Minute), url, `{"a":1}`) },
+			verifier: Verifier{Key: newKey},
+			want:     ErrExpired,
+		},
+		{
+			name:     "from the future",
+			request:  func() *http.Request { return signedRequest(newKey, now.Add(6*time.Minute), url, `{"a":1}`) },
+			verifier: Verifier{Key: newKey},
+			want:     ErrExpired,
+		},
+		{
+			name:     "custom tolerance",
+			request:  func() *http.Request { return signedRequest(newKey, now.Add(-6*time.Minute), url, `{"a":1}`) },
+			verifier: Verifier{Key: newKey, Tolerance: 10 * time.Minute},
+		},
+		{
+			name: "missing headers",
+			request: func() *http.Request {
+				r := signedRequest(newKey, now, url, `{"a":1}`)
+				r.Header.Del(DefaultSignatureHeader)
+				return r
+			},
+			verifier: Verifier{Key: newKey},
+			want:     ErrMissing,
+		},
+		{
+			name:     "rotation accepts previous key",
+			request:  func() *http.Request { return signedRequest(oldKey, now, url, `{"a":1}`) },
+			verifier: Verifier{Key: newKey, PreviousKeys: [][]byte{oldKey}},
+		},
+		{
+			name:     "rotation accepts new key",
+			request:  func() *http.Request { return signedRequest(newKey, now, url, `{"a":1}`) },
+			verifier: Verifier{Key: newKey, PreviousKeys: [][]byte{oldKey}},
+		},
+		{
+			name:     "rotation finished",
+			request:  func() *http.Request { return signedRequest(oldKey, now, url, `{"a":1}`) },
+			verifier: Verifier{Key: newKey},
+			want:     ErrMismatch,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.verifier.Now = func() time.Time { return now }
+			r := tt.request()
+			err := tt.verifier.VerifyRequest(r, tt.verifyURL)
+			if !errors.Is(err, tt.want) {
+				t.Fatalf("VerifyRequest = %v, want %v", err, tt.want)
+			}
+			// The body must still be readable by the handler.
+			if body, _ := io.ReadAll(r.Body); len(body) == 0 {
+				t.Error("body was consumed")
+			}
+		})
+	}
+}
@@ -0,0 +1,268 @@
+package workers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"regexp"
+	entity "scheduler/application/entity"
+	"scheduler/database"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type pathStep struct {
+	key     string
+	index   int
+	isIndex bool
+}
+
+func taskSuccessCriteria(task database.Task) entity.SuccessCriteria {
+	var criteria entity.SuccessCriteria
+	if task.SuccessCriteria != nil {
+		json.Unmarshal(task.SuccessCriteria, &criteria)
+	}
+	return criteria
+}
+
+// statusCodeRanges are the status codes each action type reports. Types
+// missing here report none, so status codes can't be matched for them.
+var statusCodeRanges = map[string][2]int{
+	DefaultActionType: {100, 599},
+	// gRPC status codes, OK through UNAUTHENTICATED.
+	"grpc": {0, 16},
+	// SMTP reply codes.
+	"email": {200, 599},
+}
+
+// ValidateSuccessCriteria checks a task's success criteria against its
+// action type.
+func ValidateSuccessCriteria(actionType string, criteria entity.SuccessCriteria) error {
+	if actionType == "" {
+		actionType = DefaultActionType
+	}
+	if len(criteria.StatusCodes) > 0 {
+		codes, ok := statusCodeRanges[actionType]
+		if !ok {
+			return fmt.Errorf("status codes don't apply to %s actions", actionType)
+		}
+		for _, code := range criteria.StatusCodes {
+			if code < codes[0] || code > codes[1] {
+				return fmt.Errorf("invalid status code %d for %s actions", code, actionType)
+			}
+		}
+	}
+
+	for i, assertion := range criteria.BodyAssertions {
+		if assertion.JSONPath == "" && assertion.Regex == "" {
+			return fmt.Errorf("body assertion %d needs a jsonpath or a regex", i)
+		}
+		if assertion.JSONPath != "" {
+			if _, err := parseJSONPath(assertion.JSONPath); err != nil {
+				return fmt.Errorf("body assertion %d: %w", i, err)
+			}
+		}
+		if assertion.Regex != "" {
+			if _, err := regexp.Compile(assertion.Regex); err != nil {
+				return fmt.Errorf("body assertion %d: %w", i, err)
+			}
+		}
+	}
+
+	for name, pattern := range criteria.RequiredHeaders {
+		if _, err := regexp.Compile(pattern); err != nil {
+			return fmt.Errorf("required header %s: %w", name, err)
+		}
+	}
+
+	return nil
+}
+
+// statusAccepted applies the task's status codes when it sets any, and
+// otherwise trusts the executor's own verdict.
+func statusAccepted(criteria entity.SuccessCriteria, result Result) bool {
+	if len(criteria.StatusCodes) == 0 {
+		return result.Success
+	}
+	return slices.Contains(criteria.StatusCodes, int(result.StatusCode))
+}
+
+// checkResponse returns a description of every criterion the response
+// failed, other than the status code which callers check separately.
+func checkResponse(criteria entity.SuccessCriteria, header http.Header, body []byte, duration time.Duration) []string {
+	var failures []string
+
+	if criteria.MaxLatencyMs > 0 && duration > time.Duration(criteria.MaxLatencyMs)*time.Millisecond {
+		failures = append(failures, fmt.Sprintf("latency %dms exceeded %dms", duration.Milliseconds(), criteria.MaxLatencyMs))
+	}
+
+	for name, pattern := range criteria.RequiredHeaders {
+		values, ok := header[http.CanonicalHeaderKey(name)]
+		if !ok {
+			failures = append(failures, fmt.Sprintf("header %s missing", name))
+			continue
+		}
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			failures = append(failures, fmt.Sprintf("header %s: %v", name, err))
+			continue
+		}
+		if !slices.ContainsFunc(values, re.MatchString) {
+			failures = append(failures, fmt.Sprintf("header %s does not match %q", name, pattern))
+		}
+	}
+
+	var doc interface{}
+	var docErr error
+	parsed := false
+	for _, assertion := range criteria.BodyAssertions {
+		if assertion.JSONPath == "" {
+			re, err := regexp.Compile(assertion.Regex)
+			if err != nil {
+				failures = append(failures, err.Error())
+			} else if !re.Match(body) {
+				failures = append(failures, fmt.Sprintf("body does not match %q", assertion.Regex))
+			}
+			continue
+		}
+
+		if !parsed {
+			docErr = json.Unmarshal(body, &doc)
+			parsed = true
+		}
+		if docErr != nil {
+			failures = append(failures, fmt.Sprintf("%s: body is not JSON", assertion.JSONPath))
+			continue
+		}
+
+		if failure := checkJSONPath(doc, assertion); failure != "" {
+			failures = append(failures, failure)
+		}
+	}
+
+	return failures
+}
+
+func checkJSONPath(doc interface{}, assertion entity.BodyAssertion) string {
+	steps, err := parseJSONPath(assertion.JSONPath)
+	if err != nil {
+		return err.Error()
+	}
+
+	value, ok := lookupJSONPath(doc, steps)
+	if !ok {
+		return fmt.Sprintf("%s not found", assertion.JSONPath)
+	}
+
+	if assertion.Equals != nil {
+		var want interface{}
+		raw, _ := json.Marshal(assertion.Equals)
+		json.Unmarshal(raw, &want)
+		if !reflect.DeepEqual(value, want) {
+			got, _ := json.Marshal(value)
+			return fmt.Sprintf("%s is %s, expected %s", assertion.JSONPath, got, raw)
+		}
+	}
+
+	if assertion.Regex != "" {
+		re, err := regexp.Compile(assertion.Regex)
+		if err != nil {
+			return err.Error()
+		}
+		text, isString := value.(string)
+		if !isString {
+			raw, _ := json.Marshal(value)
+			text = string(raw)
+		}
+		if !re.MatchString(text) {
+			return fmt.Sprintf("%s does not match %q", assertion.JSONPath, assertion.Regex)
+		}
+	}
+
+	return ""
+}
+
+// parseJSONPath supports the subset of JSONPath needed to address a single
+// value: $.key, $['key'] and $[index], with negative indexes counting from
+// the end of an array.
+func parseJSONPath(path string) ([]pathStep, error) {
+	if !strings.HasPrefix(path, "$") {
+		return nil, errors.New("jsonpath must start with $")
+	}
+
+	var steps []pathStep
+	rest := path[1:]
+	for rest != "" {
+		switch rest[0] {
+		case '.':
+			rest = rest[1:]
+			end := strings.IndexAny(rest, ".[")
+			if end == -1 {
+				end = len(rest)
+			}
+			if end == 0 {
+				return nil, fmt.Errorf("empty key in jsonpath %q", path)
+			}
+			steps = append(steps, pathStep{key: rest[:end]})
+			rest = rest[end:]
+
+		case '[':
+			end := strings.IndexByte(rest, ']')
+			if end == -1 {
+				return nil, fmt.Errorf("unterminated [ in jsonpath %q", path)
+			}
+			inner := rest[1:end]
+			rest = rest[end+1:]
+
+			if len(inner) >= 2 && (inner[0] == '\'' || inner[0] == '"') && inner[len(inner)-1] == inner[0] {
+				steps = append(steps, pathStep{key: inner[1 : len(inner)-1]})
+				continue
+			}
+			index, err := strconv.Atoi(inner)
+			if err != nil {
+				return nil, fmt.Errorf("invalid index %q in jsonpath %q", inner, path)
+			}
+			steps = append(steps, pathStep{index: index, isIndex: true})
+
+		default:
+			return nil, fmt.Errorf("unexpected %q in jsonpath %q", rest[0], path)
+		}
+	}
+
+	return steps, nil
+}
+
+func lookupJSONPath(doc interface{}, steps []pathStep) (interface{}, bool) {
+	current := doc
+	for _, step := range steps {
+		if step.isIndex {
+			items, ok := current.([]interface{})
+			if !ok {
+				return nil, false
+			}
+			index := step.index
+			if index < 0 {
+				index += len(items)
+			}
+			if index < 0 || index >= len(items) {
+				return nil, false
+			}
+			current = items[index]
+			continue
+		}
+
+		fields, ok := current.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		current, ok = fields[step.key]
+		if !ok {
+			return nil, false
+		}
+	}
+	return current, true
+}
@@ -0,0 +1,213 @@
+package workers
+
+import (
+	"fmt"
+	"net/http"
+	entity "scheduler/application/entity"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseJSONPath(t *testing.T) {
+	tests := []struct {
+		path  string
+		steps []pathStep
+		err   string
+	}{
+		{path: "$"},
+		{path: "$.a.b", steps: []pathStep{{key: "a"}, {key: "b"}}},
+		{path: "$.items[0].id", steps: []pathStep{{key: "items"}, {index: 0, isIndex: true}, {key: "id"}}},
+		{path: "$[-1]", steps: []pathStep{{index: -1, isIndex: true}}},
+		{path: `$['a.b']["c"]`, steps: []pathStep{{key: "a.b"}, {key: "c"}}},
+		{path: "a.b", err: "must start with $"},
+		{path: "$.", err: "empty key"},
+		{path: "$.a..b", err: "empty key"},
+		{path: "$.a[", err: "unterminated"},
+		{path: "$.a[x]", err: "invalid index"},
+		{path: "$.a[]", err: "invalid index"},
+		{path: "$a", err: "unexpected"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			steps, err := parseJSONPath(tt.path)
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Fatalf("parseJSONPath = %v, want error containing %q", err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(steps) != len(tt.steps) {
+				t.Fatalf("steps = %+v, want %+v", steps, tt.steps)
+			}
+			for i := range steps {
+				if steps[i] != tt.steps[i] {
+					t.Errorf("step %d = %+v, want %+v", i, steps[i], tt.steps[i])
+				}
+			}
+		})
+	}
+}
+
+func TestCheckResponseBodyAssertions(t *testing.T) {
+	body := []byte(`{"status":"ok","count":3,"ready":true,"data":{"items":[{"id":"a"},{"id":"b","tags":["x"]}]},"a.b":1,"none":null}`)
+
+	tests := []struct {
+		name      string
+		assertion entity.BodyAssertion
+		body      []byte
+		failure   string
+	}{
+		{name: "nested key", assertion: entity.BodyAssertion{JSONPath: "$.data.items[1].id", Equals: "b"}},
+		{name: "negative index", assertion: entity.BodyAssertion{JSONPath: "$.data.items[-2].id", Equals: "a"}},
+		{name: "quoted key", assertion: entity.BodyAssertion{JSONPath: "$['a.b']", Equals: 1}},
+		{name: "present only", assertion: entity.BodyAssertion{JSONPath: "$.data.items[1].tags[0]"}},
+		{name: "present null", assertion: entity.BodyAssertion{JSONPath: "$.none"}},
+		{name: "index out of range", assertion: entity.BodyAssertion{JSONPath: "$.data.items[2]"}, failure: "$.data.items[2] not found"},
+		{name: "negative index out of range", assertion: entity.BodyAssertion{JSONPath: "$.data.items[-3]"}, failure: "not found"},
+		{name: "missing key", assertion: entity.BodyAssertion{JSONPath: "$.data.missing"}, failure: "$.data.missing not found"},
+		{name: "index into object", assertion: entity.BodyAssertion{JSONPath: "$.data[0]"}, failure: "not found"},
+		{name: "key into array", assertion: entity.BodyAssertion{JSONPath: "$.data.items.id"}, failure: "not found"},
+		{name: "equals string", assertion: entity.BodyAssertion{JSONPath: "$.status", Equals: "ok"}},
+		{name: "equals string mismatch", assertion: entity.BodyAssertion{JSONPath: "$.status", Equals: "done"}, failure: `$.status is "ok", expected "done"`},
+		{name: "equals number", assertion: entity.BodyAssertion{JSONPath: "$.count", Equals: 3}},
+		{name: "equals number mismatch", assertion: entity.BodyAssertion{JSONPath: "$.count", Equals: 4.5}, failure: "$.count is 3, expected 4.5"},
+		{name: "equals bool", assertion: entity.BodyAssertion{JSONPath: "$.ready", Equals: true}},
+		{name: "equals type mismatch", assertion: entity.BodyAssertion{JSONPath: "$.count", Equals: "3"}, failure: `$.count is 3, expected "3"`},
+		{name: "equals object", assertion: entity.BodyAssertion{JSONPath: "$.data.items[0]", Equals: map[string]interface{}{"id": "a"}}},
+		{name: "regex on string", assertion: entity.BodyAssertion{JSONPath: "$.status", Regex: "^o"}},
+		{name: "regex on number", assertion: entity.BodyAssertion{JSONPath: "$.count", Regex: `^\d+$`}},
+		{name: "regex on array", assertion: entity.BodyAssertion{JSONPath: "$.data.items[1].tags", Regex: `^\["x"\]$`}},
+		{name: "regex mismatch", assertion: entity.BodyAssertion{JSONPath: "$.status", Regex: "^fail"}, failure: `$.status does not match "^fail"`},
+		{name: "equals and regex", assertion: entity.BodyAssertion{JSONPath: "$.status", Equals: "ok", Regex: "k$"}},
+		{name: "raw regex", assertion: entity.BodyAssertion{Regex: `"count":3`}},
+		{name: "raw regex mismatch", assertion: entity.BodyAssertion{Regex: `"count":4`}, failure: `body does not match "\"count\":4"`},
+		{name: "raw regex on text", assertion: entity.BodyAssertion{Regex: "^OK$"}, body: []byte("OK")},
+		{name: "not JSON", assertion: entity.BodyAssertion{JSONPath: "$.status"}, body: []byte("<html>ok</html>"), failure: "$.status: body is not JSON"},
+		{name: "empty body", assertion: entity.BodyAssertion{JSONPath: "$"}, body: []byte{}, failure: "body is not JSON"},
+		{name: "root", assertion: entity.BodyAssertion{JSONPath: "$", Equals: "plain"}, body: []byte(`"plain"`)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := body
+			if tt.body != nil {
+				b = tt.body
+			}
+			criteria := entity.SuccessCriteria{BodyAssertions: []entity.BodyAssertion{tt.assertion}}
+			failures := checkResponse(criteria, nil, b, 0)
+			if tt.failure == "" {
+				if len(failures) != 0 {
+					t.Errorf("failures = %q, want none", failures)
+				}
+				return
+			}
+			if len(failures) != 1 || !strings.Contains(failures[0], tt.failure) {
+				t.Errorf("failures = %q, want one containing %q", failures, tt.failure)
+			}
+		})
+	}
+}
+
+func TestCheckResponseHeadersAndLatency(t *testing.T) {
+	header := http.Header{"Content-Type": {"application/json"}, "X-Version": {"1.2", "2.0"}}
+	criteria := entity.SuccessCriteria{
+		RequiredHeaders: map[string]string{
+			"content-type": "^application/json",
+			"X-Version":    `^2\.`,
+			"X-Missing":    ".*",
+		},
+		MaxLatencyMs: 100,
+		BodyAssertions: []entity.BodyAssertion{
+			{JSONPath: "$.a"},
+			{JSONPath: "$.b"},
+		},
+	}
+
+	failures := checkResponse(criteria, header, []byte(`{"a":1}`), 150*time.Millisecond)
+	want := []string{"latency 150ms exceeded 100ms", "header X-Missing missing", "$.b not found"}
+	if len(failures) != len(want) {
+		t.Fatalf("failures = %q, want %q", failures, want)
+	}
+	for _, w := range want {
+		found := false
+		for _, f := range failures {
+			found = found || f == w
+		}
+		if !found {
+			t.Errorf("failures = %q, missing %q", failures, w)
+		}
+	}
+
+	if failures := checkResponse(criteria, header, []byte(`{"a":1,"b":2}`), 100*time.Millisecond); len(failures) != 1 {
+		t.Errorf("failures = %q, want only the missing header", failures)
+	}
+}
+
+func TestValidateSuccessCriteriaRejectsMalformedAssertions(t *testing.T) {
+	tests := []struct {
+		name     string
+		criteria entity.SuccessCriteria
+		err      string
+	}{
+		{name: "valid", criteria: entity.SuccessCriteria{BodyAssertions: []entity.BodyAssertion{{JSONPath: "$.items[0].id", Regex: "^a"}}}},
+		{name: "empty assertion", criteria: entity.SuccessCriteria{BodyAssertions: []entity.BodyAssertion{{Equals: 1}}}, err: "needs a jsonpath or a regex"},
+		{name: "no $", criteria: entity.SuccessCriteria{BodyAssertions: []entity.BodyAssertion{{JSONPath: "items[0]"}}}, err: "body assertion 0: jsonpath must start with $"},
+		{name: "bad index", criteria: entity.SuccessCriteria{BodyAssertions: []entity.BodyAssertion{{Regex: "x"}, {JSONPath: "$.items[first]"}}}, err: "body assertion 1: invalid index"},
+		{name: "unterminated", criteria: entity.SuccessCriteria{BodyAssertions: []entity.BodyAssertion{{JSONPath: "$.items[0"}}}, err: "unterminated"},
+		{name: "bad regex", criteria: entity.SuccessCriteria{BodyAssertions: []entity.BodyAssertion{{JSONPath: "$.a", Regex: "("}}}, err: "body assertion 0"},
+		{name: "bad header pattern", criteria: entity.SuccessCriteria{RequiredHeaders: map[string]string{"X-A": "["}}, err: "required header X-A"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateSuccessCriteria(DefaultActionType, tt.criteria)
+			if tt.err == "" {
+				if err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("ValidateSuccessCriteria = %v, want error containing %q", err, tt.err)
+			}
+		})
+	}
+}
+
+func TestValidateSuccessCriteriaStatusCodes(t *testing.T) {
+	tests := []struct {
+		actionType string
+		codes      []int
+		err        string
+	}{
+		{actionType: "", codes: []int{200, 204}},
+		{actionType: "http", codes: []int{100, 599}},
+		{actionType: "http", codes: []int{5}, err: "invalid status code 5 for http actions"},
+		{actionType: "http", codes: []int{600}, err: "invalid status code 600"},
+		{actionType: "grpc", codes: []int{0, 5}},
+		{actionType: "grpc", codes: []int{16}},
+		{actionType: "grpc", codes: []int{200}, err: "invalid status code 200 for grpc actions"},
+		{actionType: "grpc", codes: []int{-1}, err: "invalid status code -1"},
+		{actionType: "email", codes: []int{250}},
+		{actionType: "email", codes: []int{0}, err: "invalid status code 0 for email actions"},
+		{actionType: "command", codes: []int{0}, err: "status codes don't apply to command actions"},
+		{actionType: "sql", codes: []int{0}, err: "status codes don't apply to sql actions"},
+		{actionType: "sql"},
+	}
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%s %v", tt.actionType, tt.codes), func(t *testing.T) {
+			err := ValidateSuccessCriteria(tt.actionType, entity.SuccessCriteria{StatusCodes: tt.codes})
+			if tt.err == "" {
+				if err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("ValidateSuccessCriteria = %v, want error containing %q", err, tt.err)
+			}
+		})
+	}
+}
@@ -0,0 +1,264 @@
+package workers
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	entity "scheduler/application/entity"
+	"scheduler/database"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// defaultTokenLifetime is assumed when a token response has no
+	// expires_in.
+	defaultTokenLifetime = 5 * time.Minute
+	maxTokenRefreshLead  = time.Minute
+)
+
+func taskAuth(task database.Task) *entity.AuthConfig {
+	if task.ActionAuth == nil || string(task.ActionAuth) == "null" {
+		return nil
+	}
+	var auth entity.AuthConfig
+	if err := json.Unmarshal(task.ActionAuth, &auth); err != nil {
+		return nil
+	}
+	return &auth
+}
+
+func checkAuth(actionType string, auth entity.AuthConfig) error {
+	if actionType != "" && actionType != DefaultActionType {
+		return fmt.Errorf("auth is only supported for %s actions", DefaultActionType)
+	}
+	switch auth.Type {
+	case "oauth2":
+		u, err := url.Parse(auth.TokenURL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return errors.New("token_url must be an absolute http(s) URL")
+		}
+		if auth.ClientID == "" || auth.ClientSecretRef == "" {
+			return errors.New("client_id and client_secret_ref are required for oauth2")
+		}
+	case "basic":
+		if auth.Username == "" || auth.PasswordRef == "" {
+			return errors.New("username and password_ref are required for basic auth")
+		}
+	default:
+		return fmt.Errorf("auth type must be oauth2 or basic, got %q", auth.Type)
+	}
+	return nil
+}
+
+// AuthSecrets returns the names of the secrets an auth config references.
+func AuthSecrets(auth entity.AuthConfig) []string {
+	switch auth.Type {
+	case "oauth2":
+		return []string{auth.ClientSecretRef}
+	case "basic":
+		return []string{auth.PasswordRef}
+	}
+	return nil
+}
+
+type cachedToken struct {
+	mu        sync.Mutex
+	token     string
+	refreshAt time.Time
+}
+
+// tokenCache holds OAuth2 tokens per credential so tasks sharing a client
+// share its token. Each entry has its own lock so only one fetch per
+// credential is in flight.
+type tokenCache struct {
+	mu     sync.Mutex
+	tokens map[string]*cachedToken
+}
+
+func newTokenCache() *tokenCache {
+	return &tokenCache{tokens: make(map[string]*cachedToken)}
+}
+
+func tokenKey(auth entity.AuthConfig, clientSecret string) string {
+	h := sha256.New()
+	for _, part := range []string{auth.TokenURL, auth.ClientID, clientSecret, strings.Join(auth.Scopes, " "), auth.Audience, strconv.FormatBool(auth.CredentialsInBody)} {
+		h.Write([]byte(part))
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func (c *tokenCache) entry(key string) *cachedToken {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry, ok := c.tokens[key]
+	if !ok {
+		entry = &cachedToken{}
+		c.tokens[key] = entry
+	}
+	return entry
+}
+
+// invalidate drops a token the target rejected so the next attempt fetches a
+// fresh one.
+func (c *tokenCache) invalidate(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.tokens, key)
+}
+
+func (c *tokenCache) token(ctx context.Context, client *http.Client, auth entity.AuthConfig, clientSecret, key string, now func() time.Time) (string, error) {
+	entry := c.entry(key)
+	entry.mu.Lock()
+	defer entry.mu.Unlock()
+
+	if entry.token != "" && now().Before(entry.refreshAt) {
+		return entry.token, nil
+	}
+
+	token, lifetime, err := fetchToken(ctx, client, auth, clientSecret)
+	if err != nil {
+		return "", err
+	}
+	lead := lifetime / 5
+	if lead > maxTokenRefreshLead {
+		lead = maxTokenRefreshLead
+	}
+	entry.token = token
+	entry.refreshAt = now().Add(lifetime - lead)
+	return token, nil
+}
+
+type tokenResponse struct {
+	AccessToken      string      `json:"access_token"`
+	TokenType        string      `json:"token_type"`
+	ExpiresIn        json.Number `json:"expires_in"`
+	Error            string      `json:"error"`
+	ErrorDescription string      `json:"error_description"`
+}
+
+func fetchToken(ctx context.Context, client *http.Client, auth entity.AuthConfig, clientSecret string) (string, time.Duration, error) {
+	form := url.Values{"grant_type": {"client_credentials"}}
+	if len(auth.Scopes) > 0 {
+		form.Set("scope", strings.Join(auth.Scopes, " "))
+	}
+	if auth.Audience != "" {
+		form.Set("audience", auth.Audience)
+	}
+	if auth.CredentialsInBody {
+		form.Set("client_id", auth.ClientID)
+		form.Set("client_secret", clientSecret)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, auth.TokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", 0, requestError{err}
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	if !auth.CredentialsInBody {
+		// RFC 6749 section 2.3.1 form-encodes the credentials first.
+		req.SetBasicAuth(url.QueryEscape(auth.ClientID), url.QueryEscape(clientSecret))
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", 0, fmt.Errorf("token request: %w", err)
+	}
+	defer drainBody(resp.Body)
+
+	var body tokenResponse
+	raw, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return "", 0, fmt.Errorf("reading token response: %w", err)
+	}
+	decodeErr := json.Unmarshal(raw, &body)
+
+	if resp.StatusCode != http.StatusOK {
+		err := fmt.Errorf("token endpoint returned %d", resp.StatusCode)
+		if body.Error != "" {
+			err = fmt.Errorf("token endpoint returned %d: %s %s", resp.StatusCode, body.Error, body.ErrorDescription)
+		}
+		// Rejected credentials or scopes won't be accepted on a retry.
+		if resp.StatusCode >= 400 && resp.StatusCode < 500 && resp.StatusCode != http.StatusTooManyRequests {
+			return "", 0, requestError{err}
+		}
+		return "", 0, err
+	}
+	if decodeErr != nil || body.AccessToken == "" {
+		return "", 0, errors.New("token endpoint returned no access_token")
+	}
+	if body.TokenType != "" && !strings.EqualFold(body.TokenType, "bearer") {
+		return "", 0, requestError{fmt.Errorf("unsupported token type %q", body.TokenType)}
+	}
+
+	lifetime := defaultTokenLifetime
+	if seconds, err := body.ExpiresIn.Int64(); err == nil && seconds > 0 {
+		lifetime = time.Duration(seconds) * time.Second
+	}
+	return body.AccessToken, lifetime, nil
+}
+
+// authorize adds the Authorization header for target. For oauth2 it also
+// returns the token's cache key so a rejected token can be dropped. The
+// token endpoint is reached with the task's TLS settings and proxy.
+func (e HTTPExecutor) authorize(ctx context.Context, target entity.TargetData, auth entity.AuthConfig, secrets *secretResolver, timeouts entity.TimeoutConfig, tlsConfig *tls.Config, proxy string) (entity.TargetData, string, error) {
+	var header, key string
+
+	switch auth.Type {
+	case "basic":
+		password, err := secrets.resolve(auth.PasswordRef)
+		if err != nil {
+			return target, "", requestError{fmt.Errorf("auth: %w", err)}
+		}
+		header = "Basic " + base64.StdEncoding.EncodeToString([]byte(auth.Username+":"+password))
+
+	case "oauth2":
+		clientSecret, err := secrets.resolve(auth.ClientSecretRef)
+		if err != nil {
+			return target, "", requestError{fmt.Errorf("auth: %w", err)}
+		}
+		client, err := e.transports.client(timeouts, tlsConfig, proxy)
+		if err != nil {
+			return target, "", err
+		}
+		ctx, cancel := context.WithTimeout(ctx, time.Duration(timeouts.TotalMs)*time.Millisecond)
+		defer cancel()
+		key = tokenKey(auth, clientSecret)
+		token, err := e.tokens.token(ctx, client, auth, clientSecret, key, e.clock.Now)
+		if err != nil {
+			return target, "", fmt.Errorf("auth: %w", err)
+		}
+		header = "Bearer " + token
+		secrets.remember("auth:"+key, token)
+
+	default:
+		return target, "", requestError{fmt.Errorf("unsupported auth type %q", auth.Type)}
+	}
+
+	if auth.Type == "basic" {
+		// The encoded header is as sensitive as the password it carries.
+		secrets.remember("auth:basic", header)
+	}
+
+	headers := make(map[string]string, len(target.Headers)+1)
+	for k, v := range target.Headers {
+		if !strings.EqualFold(k, "Authorization") {
+			headers[k] = v
+		}
+	}
+	headers["Authorization"] = header
+	target.Headers = headers
+	return target, key, nil
+}
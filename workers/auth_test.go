@@ -0,0 +1,50 @@
+package workers
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"net/http"
+	"net/http/httptest"
+	entity "scheduler/application/entity"
+	"testing"
+)
+
+func TestAuthorizeFetchesTokenWithTaskTLS(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if id, secret, _ := r.BasicAuth(); id != "scheduler" || secret != "s3cret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"access_token":"tok","token_type":"Bearer","expires_in":300}`))
+	}))
+	defer server.Close()
+
+	roots := x509.NewCertPool()
+	roots.AddCert(server.Certificate())
+	tlsConfig := &tls.Config{RootCAs: roots}
+
+	auth := entity.AuthConfig{Type: "oauth2", TokenURL: server.URL + "/token", ClientID: "scheduler", ClientSecretRef: "client"}
+	timeouts := entity.TimeoutConfig{TotalMs: 5000}
+	target := entity.TargetData{Method: http.MethodGet, URL: "https://api.example.com/"}
+
+	t.Run("with the task's CA", func(t *testing.T) {
+		authorized, key, err := testHTTPExecutor().authorize(context.Background(), target, auth, testSecrets(t, map[string]string{"client": "s3cret"}), timeouts, tlsConfig, "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := authorized.Headers["Authorization"]; got != "Bearer tok" {
+			t.Errorf("Authorization = %q", got)
+		}
+		if key == "" {
+			t.Error("no token cache key returned")
+		}
+	})
+
+	t.Run("without it", func(t *testing.T) {
+		if _, _, err := testHTTPExecutor().authorize(context.Background(), target, auth, testSecrets(t, map[string]string{"client": "s3cret"}), timeouts, nil, ""); err == nil {
+			t.Fatal("token fetch trusted an unknown CA")
+		}
+	})
+}
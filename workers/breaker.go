@@ -0,0 +1,205 @@
+package workers
+
+import (
+	"fmt"
+	"net/http"
+	entity "scheduler/application/entity"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	breakerClosed   = "closed"
+	breakerOpen     = "open"
+	breakerHalfOpen = "half-open"
+)
+
+// BreakerConfig controls the per-host circuit breaker in front of http
+// actions. After FailureThreshold consecutive failures the circuit opens and
+// requests to the host fail immediately for OpenFor. Then one probe request
+// is let through: success closes the circuit, failure opens it again. A
+// threshold of 0 disables the breaker.
+type BreakerConfig struct {
+	FailureThreshold int
+	OpenFor          time.Duration
+}
+
+func DefaultBreakerConfig() BreakerConfig {
+	return BreakerConfig{
+		FailureThreshold: 5,
+		OpenFor:          30 * time.Second,
+	}
+}
+
+// circuitOpenError is returned instead of sending a request to a host whose
+// circuit is open.
+type circuitOpenError struct {
+	host    string
+	retryAt time.Time
+}
+
+func (e circuitOpenError) Error() string {
+	return fmt.Sprintf("circuit open for %s until %s", e.host, e.retryAt.Format(time.RFC3339))
+}
+
+type hostBreaker struct {
+	state     string
+	failures  int
+	openedAt  time.Time
+	probing   bool
+	lastError string
+}
+
+type breakerSet struct {
+	cfg BreakerConfig
+	now func() time.Time
+
+	mu    sync.Mutex
+	hosts map[string]*hostBreaker
+}
+
+func newBreakerSet(cfg BreakerConfig, now func() time.Time) *breakerSet {
+	return &breakerSet{cfg: cfg, now: now, hosts: make(map[string]*hostBreaker)}
+}
+
+// allow reports whether a request to host may be sent. The caller must
+// report the outcome with record when it is.
+func (b *breakerSet) allow(host string) error {
+	if b.cfg.FailureThreshold <= 0 {
+		return nil
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	breaker, ok := b.hosts[host]
+	if !ok {
+		return nil
+	}
+	switch breaker.state {
+	case breakerOpen:
+		retryAt := breaker.openedAt.Add(b.cfg.OpenFor)
+		if b.now().Before(retryAt) {
+			return circuitOpenError{host: host, retryAt: retryAt}
+		}
+		breaker.state = breakerHalfOpen
+		breaker.probing = true
+		return nil
+	case breakerHalfOpen:
+		if breaker.probing {
+			return circuitOpenError{host: host, retryAt: b.now().Add(b.cfg.OpenFor)}
+		}
+		breaker.probing = true
+	}
+	return nil
+}
+
+// breakerFailure reports whether a result says the host is unhealthy.
+// Client errors and cancelled requests say nothing about the host.
+func breakerFailure(result Result) (failed, counts bool) {
+	if result.Err != nil {
+		switch errorClass(result.Err) {
+		case errorClassRequest, errorClassCanceled:
+			return false, false
+		}
+		return true, true
+	}
+	if result.StatusCode >= http.StatusInternalServerError || result.StatusCode == http.StatusTooManyRequests {
+		return true, true
+	}
+	return false, true
+}
+
+func (b *breakerSet) record(host string, result Result) {
+	if b.cfg.FailureThreshold <= 0 {
+		return
+	}
+	failed, counts := breakerFailure(result)
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	breaker, ok := b.hosts[host]
+	if !ok {
+		if !failed {
+			return
+		}
+		breaker = &hostBreaker{state: breakerClosed}
+		b.hosts[host] = breaker
+	}
+	probe := breaker.probing
+	breaker.probing = false
+	if !counts {
+		return
+	}
+
+	if !failed {
+		// Healthy hosts are forgotten so the map only holds trouble.
+		delete(b.hosts, host)
+		return
+	}
+
+	breaker.failures++
+	if result.Err != nil {
+		breaker.lastError = result.Err.Error()
+	} else {
+		breaker.lastError = fmt.Sprintf("status %d", result.StatusCode)
+	}
+	if probe || breaker.failures >= b.cfg.FailureThreshold {
+		breaker.state = breakerOpen
+		breaker.openedAt = b.now()
+	}
+}
+
+// reset closes host's circuit and returns its state beforehand.
+func (b *breakerSet) reset(host string) (entity.BreakerStatus, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	breaker, ok := b.hosts[host]
+	if !ok {
+		return entity.BreakerStatus{}, false
+	}
+	delete(b.hosts, host)
+	return b.statusOf(host, breaker), true
+}
+
+func (b *breakerSet) statusOf(host string, breaker *hostBreaker) entity.BreakerStatus {
+	status := entity.BreakerStatus{
+		Host:      host,
+		State:     breaker.state,
+		Failures:  breaker.failures,
+		LastError: breaker.lastError,
+	}
+	if breaker.state != breakerClosed {
+		openedAt := breaker.openedAt
+		retryAt := openedAt.Add(b.cfg.OpenFor)
+		status.OpenedAt = &openedAt
+		status.RetryAt = &retryAt
+	}
+	return status
+}
+
+func (b *breakerSet) status() []entity.BreakerStatus {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	statuses := []entity.BreakerStatus{}
+	for host, breaker := range b.hosts {
+		statuses = append(statuses, b.statusOf(host, breaker))
+	}
+	sort.Slice(statuses, func(i, j int) bool {
+		return statuses[i].Host < statuses[j].Host
+	})
+	return statuses
+}
+
+// Breakers lists the hosts with recent failures and their circuit state.
+func (wp *WorkerPool) Breakers() []entity.BreakerStatus {
+	return wp.breakers.status()
+}
+
+// ResetBreaker closes the circuit for host and returns its state beforehand.
+// It reports false when the host has no recent failures.
+func (wp *WorkerPool) ResetBreaker(host string) (entity.BreakerStatus, bool) {
+	return wp.breakers.reset(host)
+}
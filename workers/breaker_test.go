@@ -0,0 +1,186 @@
+package workers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"scheduler/clock"
+	"testing"
+	"time"
+)
+
+func TestBreakerStates(t *testing.T) {
+	var (
+		healthy  = &Result{StatusCode: http.StatusOK}
+		notFound = &Result{StatusCode: http.StatusNotFound}
+		failure  = &Result{StatusCode: http.StatusServiceUnavailable}
+		refused  = &Result{Err: errors.New("connection refused")}
+		invalid  = &Result{Err: requestError{errors.New("bad url")}}
+		canceled = &Result{Err: context.Canceled}
+	)
+
+	// Each step moves the clock, asks allow, records the result when the
+	// request was let through and checks the host's state afterwards ("" is
+	// a forgotten, healthy host).
+	type step struct {
+		advance time.Duration
+		allowed bool
+		result  *Result
+		state   string
+	}
+	opened := []step{
+		{allowed: true, result: failure, state: breakerClosed},
+		{allowed: true, result: refused, state: breakerClosed},
+		{allowed: true, result: failure, state: breakerOpen},
+	}
+	then := func(base []step, more ...step) []step {
+		return append(append([]step{}, base...), more...)
+	}
+	probe := then(opened, step{advance: 30 * time.Second, allowed: true, state: breakerHalfOpen})
+
+	tests := []struct {
+		name      string
+		threshold int
+		steps     []step
+	}{
+		{
+			name:      "opens after threshold failures",
+			threshold: 3,
+			steps:     opened,
+		},
+		{
+			name:      "healthy result resets the count",
+			threshold: 3,
+			steps: []step{
+				{allowed: true, result: failure, state: breakerClosed},
+				{allowed: true, result: failure, state: breakerClosed},
+				{allowed: true, result: notFound, state: ""},
+				{allowed: true, result: failure, state: breakerClosed},
+				{allowed: true, result: failure, state: breakerClosed},
+			},
+		},
+		{
+			name:      "client errors do not count",
+			threshold: 2,
+			steps: []step{
+				{allowed: true, result: failure, state: breakerClosed},
+				{allowed: true, result: invalid, state: breakerClosed},
+				{allowed: true, result: canceled, state: breakerClosed},
+				{allowed: true, result: failure, state: breakerOpen},
+			},
+		},
+		{
+			name:      "rejects while open",
+			threshold: 3,
+			steps: then(opened,
+				step{allowed: false, state: breakerOpen},
+				step{advance: 29 * time.Second, allowed: false, state: breakerOpen},
+			),
+		},
+		{
+			name:      "one probe after the cooldown",
+			threshold: 3,
+			steps: then(probe,
+				step{allowed: false, state: breakerHalfOpen},
+			),
+		},
+		{
+			name:      "healthy probe closes",
+			threshold: 3,
+			steps: then(probe,
+				step{result: healthy, state: ""},
+				step{allowed: true, result: failure, state: breakerClosed},
+			),
+		},
+		{
+			name:      "failed probe reopens",
+			threshold: 3,
+			steps: then(probe,
+				step{result: failure, state: breakerOpen},
+				step{allowed: false, state: breakerOpen},
+				step{advance: 30 * time.Second, allowed: true, state: breakerHalfOpen},
+			),
+		},
+		{
+			name:      "non-counting probe result allows another probe",
+			threshold: 3,
+			steps: then(probe,
+				step{result: invalid, state: breakerHalfOpen},
+				step{allowed: true, result: canceled, state: breakerHalfOpen},
+				step{allowed: true, state: breakerHalfOpen},
+				step{allowed: false, state: breakerHalfOpen},
+			),
+		},
+		{
+			name:      "disabled",
+			threshold: 0,
+			steps: []step{
+				{allowed: true, result: failure, state: ""},
+				{allowed: true, result: failure, state: ""},
+				{allowed: true, state: ""},
+			},
+		},
+	}
+
+	const host = "api.example.com"
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clk := clock.NewFake(time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC))
+			breakers := newBreakerSet(BreakerConfig{FailureThreshold: tt.threshold, OpenFor: 30 * time.Second}, clk.Now)
+
+			for i, s := range tt.steps {
+				clk.Advance(s.advance)
+				// A step with only a result reports the outcome of the
+				// request the previous step let through.
+				if s.allowed || s.result == nil {
+					err := breakers.allow(host)
+					if allowed := err == nil; allowed != s.allowed {
+						t.Fatalf("step %d: allow = %v, want allowed %v", i, err, s.allowed)
+					}
+					var open circuitOpenError
+					if err != nil && !errors.As(err, &open) {
+						t.Fatalf("step %d: allow = %T, want circuitOpenError", i, err)
+					}
+				}
+				if s.result != nil {
+					breakers.record(host, *s.result)
+				}
+
+				state := ""
+				if breaker, ok := breakers.hosts[host]; ok {
+					state = breaker.state
+				}
+				if state != s.state {
+					t.Fatalf("step %d: state = %q, want %q", i, state, s.state)
+				}
+			}
+		})
+	}
+}
+
+func TestBreakerStatus(t *testing.T) {
+	start := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	clk := clock.NewFake(start)
+	breakers := newBreakerSet(BreakerConfig{FailureThreshold: 1, OpenFor: time.Minute}, clk.Now)
+
+	breakers.record("b.example.com", Result{StatusCode: http.StatusBadGateway})
+	breakers.record("a.example.com", Result{Err: errors.New("connection refused")})
+
+	statuses := breakers.status()
+	if len(statuses) != 2 || statuses[0].Host != "a.example.com" || statuses[1].Host != "b.example.com" {
+		t.Fatalf("status = %+v", statuses)
+	}
+	if s := statuses[1]; s.State != breakerOpen || s.LastError != "status 502" || !s.RetryAt.Equal(start.Add(time.Minute)) {
+		t.Errorf("b.example.com = %+v", s)
+	}
+
+	if _, ok := breakers.reset("a.example.com"); !ok {
+		t.Error("reset a.example.com = false")
+	}
+	if err := breakers.allow("a.example.com"); err != nil {
+		t.Errorf("allow after reset = %v", err)
+	}
+	if _, ok := breakers.reset("a.example.com"); ok {
+		t.Error("second reset = true")
+	}
+}
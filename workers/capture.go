@@ -0,0 +1,115 @@
+package workers
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	entity "scheduler/application/entity"
+	"scheduler/database"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	bodyEncodingJSON   = "json"
+	bodyEncodingText   = "text"
+	bodyEncodingBase64 = "base64"
+)
+
+type capturedBody struct {
+	raw       []byte
+	stored    []byte
+	encoding  string
+	truncated bool
+}
+
+func taskCapture(task database.Task, cfg Config) entity.CaptureConfig {
+	var capture entity.CaptureConfig
+	if task.Capture != nil {
+		json.Unmarshal(task.Capture, &capture)
+	}
+
+	if capture.MaxBodyBytes <= 0 || capture.MaxBodyBytes > cfg.MaxBodyBytes {
+		capture.MaxBodyBytes = cfg.MaxBodyBytes
+	}
+
+	return capture
+}
+
+// captureBody reads at most capture.MaxBodyBytes of the body and encodes it
+// for storage according to its content type. The body is still read when it
+// is discarded if the caller needs it for assertions.
+func captureBody(body io.Reader, contentType string, capture entity.CaptureConfig, needRaw bool) (capturedBody, error) {
+	if capture.DiscardBody && !needRaw {
+		return capturedBody{}, nil
+	}
+
+	raw, err := io.ReadAll(io.LimitReader(body, capture.MaxBodyBytes+1))
+	if err != nil {
+		return capturedBody{}, err
+	}
+
+	captured := capturedBody{raw: raw}
+	if int64(len(raw)) > capture.MaxBodyBytes {
+		captured.raw = raw[:capture.MaxBodyBytes]
+		captured.truncated = true
+	}
+	if capture.DiscardBody || len(captured.raw) == 0 {
+		return captured, nil
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	switch {
+	case !captured.truncated && json.Valid(captured.raw) && (isJSONMediaType(mediaType) || mediaType == ""):
+		captured.stored = captured.raw
+		captured.encoding = bodyEncodingJSON
+
+	case isTextMediaType(mediaType) || (mediaType == "" && utf8.Valid(captured.raw)):
+		text := strings.ToValidUTF8(string(captured.raw), "�")
+		if captured.truncated {
+			text += fmt.Sprintf("\n...[truncated after %d bytes]", len(captured.raw))
+		}
+		captured.stored, _ = json.Marshal(text)
+		captured.encoding = bodyEncodingText
+
+	default:
+		captured.stored, _ = json.Marshal(base64.StdEncoding.EncodeToString(captured.raw))
+		captured.encoding = bodyEncodingBase64
+	}
+
+	return captured, nil
+}
+
+// captureHeaders keeps the response headers on the task's allowlist, or
+// all of them when it has none.
+func captureHeaders(header http.Header, capture entity.CaptureConfig) http.Header {
+	if header == nil || len(capture.Headers) == 0 {
+		return header
+	}
+	kept := make(http.Header, len(capture.Headers))
+	for _, name := range capture.Headers {
+		key := http.CanonicalHeaderKey(name)
+		if values, ok := header[key]; ok {
+			kept[key] = values
+		}
+	}
+	return kept
+}
+
+func isJSONMediaType(mediaType string) bool {
+	return mediaType == "application/json" || strings.HasSuffix(mediaType, "+json")
+}
+
+func isTextMediaType(mediaType string) bool {
+	if strings.HasPrefix(mediaType, "text/") || isJSONMediaType(mediaType) || strings.HasSuffix(mediaType, "+xml") {
+		return true
+	}
+	switch mediaType {
+	case "application/xml", "application/javascript", "application/x-www-form-urlencoded", "application/yaml":
+		return true
+	}
+	return false
+}
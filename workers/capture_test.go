@@ -0,0 +1,236 @@
+package workers
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	entity "scheduler/application/entity"
+	"scheduler/database"
+	"strings"
+	"testing"
+)
+
+func TestCaptureBody(t *testing.T) {
+	binary := []byte{0x89, 'P', 'N', 'G', 0x00, 0xff, 0xfe}
+
+	tests := []struct {
+		name        string
+		body        []byte
+		contentType string
+		capture     entity.CaptureConfig
+		needRaw     bool
+		encoding    string
+		stored      string
+		raw         string
+		truncated   bool
+	}{
+		{
+			name:        "json",
+			body:        []byte(`{"a":1}`),
+			contentType: "application/json; charset=utf-8",
+			encoding:    bodyEncodingJSON,
+			stored:      `{"a":1}`,
+		},
+		{
+			name:        "json suffix",
+			body:        []byte(`[1,2]`),
+			contentType: "application/problem+json",
+			encoding:    bodyEncodingJSON,
+			stored:      `[1,2]`,
+		},
+		{
+			name:     "json without content type",
+			body:     []byte(`{"a":1}`),
+			encoding: bodyEncodingJSON,
+			stored:   `{"a":1}`,
+		},
+		{
+			name:        "invalid json is text",
+			body:        []byte(`{"a":`),
+			contentType: "application/json",
+			encoding:    bodyEncodingText,
+			stored:      `"{\"a\":"`,
+		},
+		{
+			name:        "text",
+			body:        []byte("hello"),
+			contentType: "text/plain",
+			encoding:    bodyEncodingText,
+			stored:      `"hello"`,
+		},
+		{
+			name:        "truncated text",
+			body:        []byte("hello world"),
+			contentType: "text/plain",
+			capture:     entity.CaptureConfig{MaxBodyBytes: 5},
+			encoding:    bodyEncodingText,
+			stored:      `"hello\n...[truncated after 5 bytes]"`,
+			raw:         "hello",
+			truncated:   true,
+		},
+		{
+			name:        "truncated json is stored as text",
+			body:        []byte(`{"items":[1,2,3]}`),
+			contentType: "application/json",
+			capture:     entity.CaptureConfig{MaxBodyBytes: 10},
+			encoding:    bodyEncodingText,
+			stored:      `"{\"items\":[\n...[truncated after 10 bytes]"`,
+			raw:         `{"items":[`,
+			truncated:   true,
+		},
+		{
+			name:        "exactly at the limit",
+			body:        []byte("hello"),
+			contentType: "text/plain",
+			capture:     entity.CaptureConfig{MaxBodyBytes: 5},
+			encoding:    bodyEncodingText,
+			stored:      `"hello"`,
+		},
+		{
+			name:        "binary content type",
+			body:        binary,
+			contentType: "image/png",
+			encoding:    bodyEncodingBase64,
+			stored:      `"` + base64.StdEncoding.EncodeToString(binary) + `"`,
+		},
+		{
+			name:        "octet stream with text bytes",
+			body:        []byte("plain"),
+			contentType: "application/octet-stream",
+			encoding:    bodyEncodingBase64,
+			stored:      `"cGxhaW4="`,
+		},
+		{
+			name:     "binary without content type",
+			body:     binary,
+			encoding: bodyEncodingBase64,
+			stored:   `"` + base64.StdEncoding.EncodeToString(binary) + `"`,
+		},
+		{
+			name:        "truncated binary",
+			body:        binary,
+			contentType: "application/pdf",
+			capture:     entity.CaptureConfig{MaxBodyBytes: 4},
+			encoding:    bodyEncodingBase64,
+			stored:      `"` + base64.StdEncoding.EncodeToString(binary[:4]) + `"`,
+			raw:         string(binary[:4]),
+			truncated:   true,
+		},
+		{
+			name:        "empty",
+			body:        []byte{},
+			contentType: "application/json",
+		},
+		{
+			name:        "discarded",
+			body:        []byte(`{"a":1}`),
+			contentType: "application/json",
+			capture:     entity.CaptureConfig{DiscardBody: true},
+			raw:         "",
+		},
+		{
+			name:        "discarded but needed for assertions",
+			body:        []byte(`{"a":1}`),
+			contentType: "application/json",
+			capture:     entity.CaptureConfig{DiscardBody: true, MaxBodyBytes: 4},
+			needRaw:     true,
+			raw:         `{"a"`,
+			truncated:   true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			capture := tt.capture
+			if capture.MaxBodyBytes == 0 {
+				capture.MaxBodyBytes = 1024
+			}
+			got, err := captureBody(bytes.NewReader(tt.body), tt.contentType, capture, tt.needRaw)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got.encoding != tt.encoding || string(got.stored) != tt.stored {
+				t.Errorf("stored %s as %q, want %s as %q", got.stored, got.encoding, tt.stored, tt.encoding)
+			}
+			if got.stored != nil && !json.Valid(got.stored) {
+				t.Errorf("stored body %q is not valid JSON", got.stored)
+			}
+			raw := tt.raw
+			if raw == "" && !tt.truncated && !capture.DiscardBody {
+				raw = string(tt.body)
+			}
+			if string(got.raw) != raw {
+				t.Errorf("raw = %q, want %q", got.raw, raw)
+			}
+			if got.truncated != tt.truncated {
+				t.Errorf("truncated = %v, want %v", got.truncated, tt.truncated)
+			}
+		})
+	}
+}
+
+func TestCaptureBodyReadsNoMoreThanTheLimit(t *testing.T) {
+	body := strings.NewReader(strings.Repeat("x", 1<<20))
+	got, err := captureBody(body, "text/plain", entity.CaptureConfig{MaxBodyBytes: 16}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got.raw) != 16 || !got.truncated {
+		t.Errorf("raw = %d bytes, truncated %v", len(got.raw), got.truncated)
+	}
+	// One byte past the limit is read to tell a full body from a cut one.
+	if rest := body.Len(); rest != 1<<20-17 {
+		t.Errorf("%d bytes left unread, want %d", rest, 1<<20-17)
+	}
+}
+
+func TestTaskCaptureLimit(t *testing.T) {
+	cfg := Config{MaxBodyBytes: 1000}
+	tests := []struct {
+		capture string
+		want    int64
+	}{
+		{"", 1000},
+		{`{"max_body_bytes":10}`, 10},
+		{`{"max_body_bytes":5000}`, 1000},
+		{`{"discard_body":true}`, 1000},
+	}
+	for _, tt := range tests {
+		var task database.Task
+		if tt.capture != "" {
+			task.Capture = []byte(tt.capture)
+		}
+		if got := taskCapture(task, cfg).MaxBodyBytes; got != tt.want {
+			t.Errorf("taskCapture(%s).MaxBodyBytes = %d, want %d", tt.capture, got, tt.want)
+		}
+	}
+}
+
+func TestCaptureHeaders(t *testing.T) {
+	header := http.Header{
+		"Content-Type": {"application/json"},
+		"Set-Cookie":   {"session=abc", "theme=dark"},
+		"X-Request-Id": {"r-1"},
+	}
+
+	if got := captureHeaders(header, entity.CaptureConfig{}); len(got) != 3 {
+		t.Errorf("without an allowlist got %v, want every header", got)
+	}
+	if got := captureHeaders(nil, entity.CaptureConfig{Headers: []string{"X-Request-Id"}}); got != nil {
+		t.Errorf("nil header = %v", got)
+	}
+
+	got := captureHeaders(header, entity.CaptureConfig{Headers: []string{"x-request-id", "Set-Cookie", "ETag"}})
+	if len(got) != 2 {
+		t.Fatalf("got %v, want X-Request-Id and Set-Cookie", got)
+	}
+	if got.Get("X-Request-Id") != "r-1" || len(got.Values("Set-Cookie")) != 2 {
+		t.Errorf("got %v", got)
+	}
+	if got.Get("Content-Type") != "" {
+		t.Errorf("Content-Type was kept: %v", got)
+	}
+	if header.Get("Content-Type") == "" {
+		t.Error("the response header was modified")
+	}
+}
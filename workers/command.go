@@ -0,0 +1,196 @@
+package workers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	entity "scheduler/application/entity"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+)
+
+const defaultCommandPath = "/usr/local/sbin:/usr/local/bin:/usr/sbin:/usr/bin:/sbin:/bin"
+
+// CommandPolicy is the server side of the command executor. Tasks may only
+// run executables listed in AllowedPaths and switch to users listed in
+// AllowedUsers.
+type CommandPolicy struct {
+	AllowedPaths []string
+	AllowedUsers []string
+}
+
+// CommandExecutor runs a local executable for "command" actions. Stdout is
+// stored like a response body, stderr and the exit code alongside it.
+type CommandExecutor struct {
+	policy CommandPolicy
+}
+
+func NewCommandExecutor(policy CommandPolicy) *CommandExecutor {
+	allowed := make([]string, 0, len(policy.AllowedPaths))
+	for _, path := range policy.AllowedPaths {
+		allowed = append(allowed, filepath.Clean(path))
+	}
+	policy.AllowedPaths = allowed
+	return &CommandExecutor{policy: policy}
+}
+
+func commandConfig(raw json.RawMessage) (entity.CommandConfig, error) {
+	var cfg entity.CommandConfig
+	if len(raw) == 0 {
+		return cfg, errors.New("config is required for command actions")
+	}
+	if err := json.Unmarshal(raw, &cfg); err != nil {
+		return cfg, fmt.Errorf("invalid command config: %w", err)
+	}
+	return cfg, nil
+}
+
+func (e *CommandExecutor) ValidateConfig(action entity.ActionData) error {
+	cfg, err := commandConfig(action.Config)
+	if err != nil {
+		return err
+	}
+	return e.check(cfg)
+}
+
+func (e *CommandExecutor) check(cfg entity.CommandConfig) error {
+	if cfg.Path == "" {
+		return errors.New("path is required for command actions")
+	}
+	if !filepath.IsAbs(cfg.Path) {
+		return fmt.Errorf("path %q must be absolute", cfg.Path)
+	}
+	if !slices.Contains(e.policy.AllowedPaths, filepath.Clean(cfg.Path)) {
+		return fmt.Errorf("%s is not on the command allowlist", cfg.Path)
+	}
+	if cfg.Dir != "" && !filepath.IsAbs(cfg.Dir) {
+		return fmt.Errorf("dir %q must be absolute", cfg.Dir)
+	}
+	for name := range cfg.Env {
+		if name == "" || strings.ContainsAny(name, "=\x00") {
+			return fmt.Errorf("invalid environment variable name %q", name)
+		}
+	}
+	if cfg.MaxOutputBytes < 0 {
+		return errors.New("max_output_bytes must not be negative")
+	}
+	if cfg.User != "" && !slices.Contains(e.policy.AllowedUsers, cfg.User) {
+		return fmt.Errorf("running as %s is not allowed", cfg.User)
+	}
+	return checkSandbox(cfg)
+}
+
+func (e *CommandExecutor) Execute(ctx context.Context, job Execution) Result {
+	result := Result{Request: entity.DeadLetterRequest{Config: job.Config}}
+
+	cfg, err := commandConfig(job.Config)
+	if err == nil {
+		// The allowlist is checked again because it may have shrunk since
+		// the task was created.
+		err = e.check(cfg)
+	}
+	if err != nil {
+		result.Err = requestError{err}
+		return result
+	}
+
+	limit := job.Capture.MaxBodyBytes
+	if cfg.MaxOutputBytes > 0 && cfg.MaxOutputBytes < limit {
+		limit = cfg.MaxOutputBytes
+	}
+	// One byte over the limit is kept so truncation can be detected.
+	stdout := &limitedBuffer{limit: limit + 1}
+	stderr := &limitedBuffer{limit: limit + 1}
+
+	cmd := exec.CommandContext(ctx, cfg.Path, cfg.Args...)
+	cmd.Env = commandEnv(cfg.Env)
+	cmd.Dir = cfg.Dir
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+	if cfg.Stdin != "" {
+		cmd.Stdin = strings.NewReader(cfg.Stdin)
+	}
+	// Don't wait forever on output pipes held open by orphaned children.
+	cmd.WaitDelay = time.Second
+
+	if err := sandboxCommand(cmd, cfg); err != nil {
+		result.Err = requestError{err}
+		return result
+	}
+
+	start := time.Now()
+	if err := startCommand(cmd, cfg.Limits); err != nil {
+		result.Err = requestError{err}
+		return result
+	}
+	err = cmd.Wait()
+	result.Duration = time.Since(start)
+
+	exitCode := int32(cmd.ProcessState.ExitCode())
+	result.ExitCode = &exitCode
+	if ctx.Err() != nil {
+		result.Err = ctx.Err()
+	} else if err != nil {
+		result.Err = err
+	}
+	result.Success = result.Err == nil
+
+	capture := entity.CaptureConfig{MaxBodyBytes: limit, DiscardBody: job.Capture.DiscardBody}
+	result.Body, _ = captureBody(bytes.NewReader(stdout.buf.Bytes()), "", capture, job.NeedBody)
+	result.Stderr = truncatedText(stderr.buf.Bytes(), limit)
+
+	return result
+}
+
+func commandEnv(env map[string]string) []string {
+	names := make([]string, 0, len(env))
+	for name := range env {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	vars := make([]string, 0, len(names)+1)
+	for _, name := range names {
+		vars = append(vars, name+"="+env[name])
+	}
+	if _, ok := env["PATH"]; !ok {
+		vars = append(vars, "PATH="+defaultCommandPath)
+	}
+	return vars
+}
+
+func truncatedText(raw []byte, limit int64) string {
+	text := raw
+	if int64(len(raw)) > limit {
+		text = raw[:limit]
+	}
+	s := strings.ToValidUTF8(string(text), "�")
+	if int64(len(raw)) > limit {
+		s += fmt.Sprintf("\n...[truncated after %d bytes]", limit)
+	}
+	return s
+}
+
+// limitedBuffer keeps the first limit bytes written to it and discards the
+// rest, so a noisy process never fails on a closed pipe.
+type limitedBuffer struct {
+	buf   bytes.Buffer
+	limit int64
+}
+
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	if remaining := b.limit - int64(b.buf.Len()); remaining > 0 {
+		if int64(len(p)) > remaining {
+			b.buf.Write(p[:remaining])
+		} else {
+			b.buf.Write(p)
+		}
+	}
+	return len(p), nil
+}
@@ -0,0 +1,126 @@
+//go:build linux
+
+package workers
+
+import (
+	"fmt"
+	"os/exec"
+	"os/user"
+	"runtime"
+	entity "scheduler/application/entity"
+	"strconv"
+	"syscall"
+
+	"golang.org/x/sys/unix"
+)
+
+func checkSandbox(cfg entity.CommandConfig) error {
+	if cfg.User == "" {
+		return nil
+	}
+	_, err := lookupCredential(cfg.User)
+	return err
+}
+
+// sandboxCommand puts the process in its own process group, so a timeout
+// kills anything it spawned too, and switches to the configured user. The
+// worker needs CAP_SETUID and CAP_SETGID for the latter, and CAP_SYS_RESOURCE
+// to also set limits on a process running as another user.
+func sandboxCommand(cmd *exec.Cmd, cfg entity.CommandConfig) error {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	cmd.Cancel = func() error {
+		return syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+	}
+
+	if cfg.User != "" {
+		cred, err := lookupCredential(cfg.User)
+		if err != nil {
+			return err
+		}
+		cmd.SysProcAttr.Credential = cred
+	}
+	return nil
+}
+
+func lookupCredential(name string) (*syscall.Credential, error) {
+	u, err := user.Lookup(name)
+	if err != nil {
+		return nil, err
+	}
+	uid, err := strconv.ParseUint(u.Uid, 10, 32)
+	if err != nil {
+		return nil, fmt.Errorf("user %s: invalid uid %q", name, u.Uid)
+	}
+	gid, err := strconv.ParseUint(u.Gid, 10, 32)
+	if err != nil {
+		return nil, fmt.Errorf("user %s: invalid gid %q", name, u.Gid)
+	}
+
+	cred := &syscall.Credential{Uid: uint32(uid), Gid: uint32(gid)}
+	groups, _ := u.GroupIds()
+	for _, g := range groups {
+		if id, err := strconv.ParseUint(g, 10, 32); err == nil {
+			cred.Groups = append(cred.Groups, uint32(id))
+		}
+	}
+	return cred, nil
+}
+
+// startCommand starts the process with the given resource limits. os/exec
+// has no hook between fork and exec, so when limits are set the child is
+// started under ptrace, which stops it right after exec, and only resumed
+// once the limits are in place.
+func startCommand(cmd *exec.Cmd, limits *entity.CommandLimits) error {
+	if limits == nil {
+		return cmd.Start()
+	}
+
+	// The tracer is the thread that started the child, so detaching has to
+	// happen on the same thread.
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	cmd.SysProcAttr.Ptrace = true
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	pid := cmd.Process.Pid
+
+	var status syscall.WaitStatus
+	if _, err := syscall.Wait4(pid, &status, 0, nil); err != nil || !status.Stopped() {
+		cmd.Process.Kill()
+		cmd.Wait()
+		return fmt.Errorf("waiting for command to start: %v", err)
+	}
+
+	if err := applyLimits(pid, limits); err != nil {
+		cmd.Process.Kill()
+		syscall.PtraceDetach(pid)
+		cmd.Wait()
+		return err
+	}
+	return syscall.PtraceDetach(pid)
+}
+
+func applyLimits(pid int, limits *entity.CommandLimits) error {
+	for _, l := range []struct {
+		name     string
+		resource int
+		value    uint64
+	}{
+		{"cpu_seconds", unix.RLIMIT_CPU, limits.CPUSeconds},
+		{"memory_bytes", unix.RLIMIT_AS, limits.MemoryBytes},
+		{"open_files", unix.RLIMIT_NOFILE, limits.OpenFiles},
+		{"processes", unix.RLIMIT_NPROC, limits.Processes},
+		{"file_bytes", unix.RLIMIT_FSIZE, limits.FileBytes},
+	} {
+		if l.value == 0 {
+			continue
+		}
+		rlimit := unix.Rlimit{Cur: l.value, Max: l.value}
+		if err := unix.Prlimit(pid, l.resource, &rlimit, nil); err != nil {
+			return fmt.Errorf("setting %s limit: %w", l.name, err)
+		}
+	}
+	return nil
+}
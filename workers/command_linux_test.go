@@ -0,0 +1,121 @@
+//go:build linux
+
+package workers
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	entity "scheduler/application/entity"
+	"strconv"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// processGone reports whether pid has exited. Orphans may be left as zombies
+// when nothing reaps them, which counts as gone.
+func processGone(pid int) bool {
+	if err := syscall.Kill(pid, 0); err == syscall.ESRCH {
+		return true
+	}
+	stat, err := os.ReadFile("/proc/" + strconv.Itoa(pid) + "/stat")
+	if err != nil {
+		return true
+	}
+	// The state follows the parenthesised command name.
+	fields := strings.Fields(string(stat[bytes.LastIndexByte(stat, ')')+1:]))
+	return len(fields) > 0 && (fields[0] == "Z" || fields[0] == "X")
+}
+
+func TestCommandTimeoutKillsProcessGroup(t *testing.T) {
+	executor := NewCommandExecutor(CommandPolicy{AllowedPaths: []string{"/bin/sh"}})
+	pidFile := filepath.Join(t.TempDir(), "child.pid")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+	defer cancel()
+	// The shell backgrounds a grandchild and waits on it; killing only the
+	// shell would leave the sleep running.
+	result := executor.Execute(ctx, commandJob(t, entity.CommandConfig{
+		Path: "/bin/sh",
+		Args: []string{"-c", `sleep 30 & echo $! > "$PID_FILE"; wait`},
+		Env:  map[string]string{"PID_FILE": pidFile},
+	}))
+	if result.Err == nil {
+		t.Fatalf("Execute = %+v, want a timeout", result)
+	}
+
+	raw, err := os.ReadFile(pidFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pid, err := strconv.Atoi(strings.TrimSpace(string(raw)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	deadline := time.Now().Add(2 * time.Second)
+	for !processGone(pid) {
+		if time.Now().After(deadline) {
+			syscall.Kill(pid, syscall.SIGKILL)
+			t.Fatalf("background process %d survived the timeout", pid)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestCommandLimits(t *testing.T) {
+	executor := NewCommandExecutor(CommandPolicy{AllowedPaths: []string{"/bin/sh"}})
+
+	result := executor.Execute(context.Background(), commandJob(t, entity.CommandConfig{
+		Path:   "/bin/sh",
+		Args:   []string{"-c", "ulimit -n; ulimit -t; ulimit -v"},
+		Limits: &entity.CommandLimits{OpenFiles: 17, CPUSeconds: 3, MemoryBytes: 512 << 20},
+	}))
+	if result.Err != nil && strings.Contains(result.Err.Error(), "operation not permitted") {
+		t.Skipf("ptrace is not permitted here: %v", result.Err)
+	}
+	if result.Err != nil {
+		t.Fatalf("Execute = %+v", result)
+	}
+	// ulimit -v reports KiB.
+	if got, want := string(result.Body.raw), "17\n3\n524288\n"; got != want {
+		t.Errorf("limits seen by the command = %q, want %q", got, want)
+	}
+
+	// The worker's own limits are untouched.
+	var rlimit syscall.Rlimit
+	if err := syscall.Getrlimit(syscall.RLIMIT_NOFILE, &rlimit); err != nil {
+		t.Fatal(err)
+	}
+	if rlimit.Cur == 17 {
+		t.Error("the open files limit leaked into the worker")
+	}
+}
+
+func TestCommandLimitsAreEnforced(t *testing.T) {
+	executor := NewCommandExecutor(CommandPolicy{AllowedPaths: []string{"/bin/sh"}})
+	out := filepath.Join(t.TempDir(), "out")
+
+	// Writing past file_bytes raises SIGXFSZ, which kills the shell.
+	result := executor.Execute(context.Background(), commandJob(t, entity.CommandConfig{
+		Path:   "/bin/sh",
+		Args:   []string{"-c", `i=0; while [ $i -lt 100 ]; do echo 0123456789012345678901234567890123456789; i=$((i+1)); done > "$OUT"`},
+		Env:    map[string]string{"OUT": out},
+		Limits: &entity.CommandLimits{FileBytes: 1024},
+	}))
+	if result.Err != nil && strings.Contains(result.Err.Error(), "operation not permitted") {
+		t.Skipf("ptrace is not permitted here: %v", result.Err)
+	}
+	if result.Success {
+		t.Fatalf("Execute = %+v, want the command to fail", result)
+	}
+	info, err := os.Stat(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Size() > 1024 {
+		t.Errorf("wrote %d bytes past a 1024 byte limit", info.Size())
+	}
+}
@@ -0,0 +1,27 @@
+//go:build !linux
+
+package workers
+
+import (
+	"errors"
+	"os/exec"
+	entity "scheduler/application/entity"
+)
+
+func checkSandbox(cfg entity.CommandConfig) error {
+	if cfg.User != "" {
+		return errors.New("running commands as another user is only supported on linux")
+	}
+	if cfg.Limits != nil {
+		return errors.New("command resource limits are only supported on linux")
+	}
+	return nil
+}
+
+func sandboxCommand(cmd *exec.Cmd, cfg entity.CommandConfig) error {
+	return checkSandbox(cfg)
+}
+
+func startCommand(cmd *exec.Cmd, limits *entity.CommandLimits) error {
+	return cmd.Start()
+}
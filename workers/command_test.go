@@ -0,0 +1,135 @@
+package workers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	entity "scheduler/application/entity"
+	"strings"
+	"testing"
+	"time"
+)
+
+func commandJob(t *testing.T, cfg entity.CommandConfig) Execution {
+	t.Helper()
+	raw, err := json.Marshal(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return Execution{Config: raw, Attempt: 1, Capture: entity.CaptureConfig{MaxBodyBytes: 1024}}
+}
+
+func TestCommandAllowlist(t *testing.T) {
+	executor := NewCommandExecutor(CommandPolicy{AllowedPaths: []string{"/bin/./sh"}})
+	action := func(path string) entity.ActionData {
+		raw, _ := json.Marshal(entity.CommandConfig{Path: path})
+		return entity.ActionData{Type: "command", Config: raw}
+	}
+
+	if err := executor.ValidateConfig(action("/bin/sh")); err != nil {
+		t.Errorf("ValidateConfig(/bin/sh) = %v", err)
+	}
+	for _, path := range []string{"/bin/echo", "sh", "/bin/sh/../echo"} {
+		if err := executor.ValidateConfig(action(path)); err == nil {
+			t.Errorf("ValidateConfig(%s) = nil", path)
+		}
+	}
+
+	// A task created while /bin/sh was allowed is refused once the
+	// server's allowlist no longer has it.
+	shrunk := NewCommandExecutor(CommandPolicy{AllowedPaths: []string{"/bin/true"}})
+	result := shrunk.Execute(context.Background(), commandJob(t, entity.CommandConfig{Path: "/bin/sh", Args: []string{"-c", "echo ran"}}))
+	if result.Err == nil || !strings.Contains(result.Err.Error(), "not on the command allowlist") {
+		t.Fatalf("Execute = %+v, want allowlist error", result)
+	}
+	if errorClass(result.Err) != errorClassRequest {
+		t.Errorf("error class = %s, want %s", errorClass(result.Err), errorClassRequest)
+	}
+	if result.ExitCode != nil || len(result.Body.raw) != 0 {
+		t.Errorf("the command ran: %+v", result)
+	}
+}
+
+func TestCommandCapturesExitCodeAndOutput(t *testing.T) {
+	executor := NewCommandExecutor(CommandPolicy{AllowedPaths: []string{"/bin/sh"}})
+
+	tests := []struct {
+		name     string
+		cfg      entity.CommandConfig
+		exitCode int32
+		stdout   string
+		stderr   string
+		success  bool
+	}{
+		{
+			name:     "success",
+			cfg:      entity.CommandConfig{Path: "/bin/sh", Args: []string{"-c", "echo out"}},
+			exitCode: 0,
+			stdout:   "out\n",
+			success:  true,
+		},
+		{
+			name:     "failure",
+			cfg:      entity.CommandConfig{Path: "/bin/sh", Args: []string{"-c", "echo out; echo err >&2; exit 3"}},
+			exitCode: 3,
+			stdout:   "out\n",
+			stderr:   "err\n",
+		},
+		{
+			name:     "env and stdin",
+			cfg:      entity.CommandConfig{Path: "/bin/sh", Args: []string{"-c", `read line; echo "$GREETING $line"`}, Env: map[string]string{"GREETING": "hello"}, Stdin: "world\n"},
+			exitCode: 0,
+			stdout:   "hello world\n",
+			success:  true,
+		},
+		{
+			name:     "worker environment is not inherited",
+			cfg:      entity.CommandConfig{Path: "/bin/sh", Args: []string{"-c", `echo "[$HOME]"`}},
+			exitCode: 0,
+			stdout:   "[]\n",
+			success:  true,
+		},
+		{
+			name:     "output is truncated",
+			cfg:      entity.CommandConfig{Path: "/bin/sh", Args: []string{"-c", "echo 0123456789; echo abcdefghij >&2; exit 1"}, MaxOutputBytes: 4},
+			exitCode: 1,
+			stdout:   "0123",
+			stderr:   "abcd\n...[truncated after 4 bytes]",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := executor.Execute(context.Background(), commandJob(t, tt.cfg))
+			if result.ExitCode == nil || *result.ExitCode != tt.exitCode {
+				t.Fatalf("exit code = %v, want %d (err %v)", result.ExitCode, tt.exitCode, result.Err)
+			}
+			if result.Success != tt.success || (result.Err == nil) != tt.success {
+				t.Errorf("success = %v, err = %v", result.Success, result.Err)
+			}
+			if string(result.Body.raw) != tt.stdout {
+				t.Errorf("stdout = %q, want %q", result.Body.raw, tt.stdout)
+			}
+			if result.Stderr != tt.stderr {
+				t.Errorf("stderr = %q, want %q", result.Stderr, tt.stderr)
+			}
+		})
+	}
+}
+
+func TestCommandTimeout(t *testing.T) {
+	executor := NewCommandExecutor(CommandPolicy{AllowedPaths: []string{"/bin/sh"}})
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	result := executor.Execute(ctx, commandJob(t, entity.CommandConfig{Path: "/bin/sh", Args: []string{"-c", "sleep 30"}}))
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Fatalf("Execute took %v", elapsed)
+	}
+	if !errors.Is(result.Err, context.DeadlineExceeded) {
+		t.Errorf("err = %v, want deadline exceeded", result.Err)
+	}
+	if result.Success || result.ExitCode == nil || *result.ExitCode != -1 {
+		t.Errorf("result = %+v, want a killed process", result)
+	}
+}
@@ -0,0 +1,62 @@
+package workers
+
+import (
+	"scheduler/secrets"
+	"time"
+)
+
+type Config struct {
+	// MinWorkers and MaxWorkers bound the pool. It starts at the minimum
+	// and, when ScaleInterval is set, grows while tasks queue up or start
+	// more than ScaleUpWait after their scheduled time, and shrinks by
+	// retiring workers idle for ScaleDownIdle. ScaleUpWait includes the
+	// scheduler's poll interval, so it should be longer than that.
+	MinWorkers    int
+	MaxWorkers    int
+	ScaleInterval time.Duration
+	ScaleUpWait   time.Duration
+	ScaleDownIdle time.Duration
+
+	// DefaultTimeout bounds each attempt of a task that doesn't set its own
+	// total timeout.
+	DefaultTimeout time.Duration
+
+	// MaxRetryAfter is the longest Retry-After a retry waits for when the
+	// task's retry policy has no max delay. A target asked to wait longer
+	// fails instead. Zero means no limit.
+	MaxRetryAfter time.Duration
+
+	// MaxBodyBytes caps how much of a response body is read and stored.
+	MaxBodyBytes int64
+
+	// Secrets decrypts the values templates reference with secret. When nil,
+	// templates that use secrets fail.
+	Secrets *secrets.Box
+
+	// Transport tunes the connection pool shared by http actions.
+	Transport TransportConfig
+
+	// Breaker guards hosts that keep failing.
+	Breaker BreakerConfig
+
+	// IdempotencyHeader is the header http actions carry the run's
+	// idempotency key in. Empty disables it; a task that sets the header
+	// itself keeps its own value.
+	IdempotencyHeader string
+}
+
+func DefaultConfig() Config {
+	return Config{
+		MinWorkers:        5,
+		MaxWorkers:        20,
+		ScaleInterval:     5 * time.Second,
+		ScaleUpWait:       45 * time.Second,
+		ScaleDownIdle:     time.Minute,
+		DefaultTimeout:    30 * time.Second,
+		MaxRetryAfter:     5 * time.Minute,
+		MaxBodyBytes:      1 << 20,
+		Transport:         DefaultTransportConfig(),
+		Breaker:           DefaultBreakerConfig(),
+		IdempotencyHeader: "Idempotency-Key",
+	}
+}
@@ -0,0 +1,180 @@
+package workers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	entity "scheduler/application/entity"
+	"scheduler/clock"
+	"scheduler/database"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+var (
+	// ErrTargetRemoved is returned by Replay when the task's targets changed
+	// and no longer include the dead letter's.
+	ErrTargetRemoved = errors.New("the task no longer has the dead letter's target")
+	ErrShuttingDown  = errors.New("the worker pool is shutting down")
+)
+
+type targetOutcome struct {
+	attemptResult
+	attempts int
+	request  entity.DeadLetterRequest
+}
+
+func requestSnapshot(target entity.TargetData) entity.DeadLetterRequest {
+	snapshot := entity.DeadLetterRequest{
+		Method:  target.Method,
+		URL:     target.URL,
+		Headers: target.Headers,
+	}
+	if target.Payload != nil {
+		snapshot.Body, _ = json.Marshal(target.Payload)
+	}
+	return snapshot
+}
+
+func (wp *WorkerPool) deadLetter(ctx context.Context, task database.Task, run database.TaskRun, index int, outcome targetOutcome) {
+	request, err := json.Marshal(outcome.request)
+	if err != nil {
+		log.Printf("Failed to encode dead letter request for task %s: %v", task.Name, err)
+		return
+	}
+
+	_, err = wp.db.CreateDeadLetter(ctx, database.CreateDeadLetterParams{
+		TaskID:       task.ID,
+		RunID:        run.ID,
+		TargetIndex:  int32(index),
+		Request:      request,
+		StatusCode:   outcome.statusCode,
+		ErrorMessage: pgtype.Text{String: outcome.errMessage, Valid: outcome.errMessage != ""},
+		ErrorClass:   pgtype.Text{String: outcome.errClass, Valid: outcome.errClass != ""},
+		Attempts:     int32(outcome.attempts),
+	})
+	if err != nil {
+		log.Printf("Failed to dead-letter task %s target %d: %v", task.Name, index, err)
+	}
+}
+
+func (wp *WorkerPool) recordFailure(ctx context.Context, task database.Task) {
+	updated, err := wp.db.RecordTaskFailure(ctx, task.ID)
+	if err != nil {
+		log.Printf("Failed to record failure for task %s: %v", task.Name, err)
+		return
+	}
+	if updated.Status == "paused" && task.Status != "paused" {
+		log.Printf("Task %s paused after %d consecutive failed runs", task.Name, updated.ConsecutiveFailures)
+	}
+}
+
+func (wp *WorkerPool) resetFailures(ctx context.Context, task database.Task) {
+	if task.ConsecutiveFailures == 0 {
+		return
+	}
+	if _, err := wp.db.ResetTaskFailures(ctx, task.ID); err != nil {
+		log.Printf("Failed to reset failures for task %s: %v", task.Name, err)
+	}
+}
+
+// Replay sends the target a dead letter recorded again, with the task's
+// current config, in a run of its own that holds only that target. The
+// original occurrence's idempotency key is reused. The task's schedule,
+// status and failure count are left alone, so a task paused by
+// pause_after_failures stays paused. A replay that fails is dead-lettered
+// again. Replay returns once the run is created; the target is sent in the
+// background.
+func (wp *WorkerPool) Replay(ctx context.Context, task database.Task, deadLetter database.DeadLetter) (database.TaskRun, error) {
+	targets, err := taskTargets(task)
+	if err != nil {
+		return database.TaskRun{}, err
+	}
+	index := int(deadLetter.TargetIndex)
+	if index < 0 || index >= len(targets) {
+		return database.TaskRun{}, ErrTargetRemoved
+	}
+
+	var original database.TaskRun
+	if deadLetter.RunID.Valid {
+		if original, err = wp.db.GetTaskRun(ctx, deadLetter.RunID); err != nil {
+			return database.TaskRun{}, err
+		}
+	}
+
+	wp.mu.Lock()
+	runCtx := wp.ctx
+	if runCtx == nil || wp.isDraining() {
+		wp.mu.Unlock()
+		return database.TaskRun{}, ErrShuttingDown
+	}
+	wp.wg.Add(1)
+	wp.mu.Unlock()
+
+	run, err := wp.db.CreateTaskRun(ctx, database.CreateTaskRunParams{
+		TaskID:       task.ID,
+		StartedAt:    pgtype.Timestamptz{Time: wp.clock.Now(), Valid: true},
+		SuccessRule:  "all",
+		TargetsTotal: 1,
+		ScheduledAt:  original.ScheduledAt,
+	})
+	if err != nil {
+		wp.wg.Done()
+		return database.TaskRun{}, err
+	}
+
+	go func() {
+		defer wp.wg.Done()
+		wp.replay(runCtx, task, run, original, index, targets[index])
+	}()
+	return run, nil
+}
+
+func (wp *WorkerPool) replay(ctx context.Context, task database.Task, run database.TaskRun, original database.TaskRun, index int, target entity.TargetData) {
+	log.Printf("Replaying task %s target %d", task.Name, index)
+
+	dbCtx := context.WithoutCancel(ctx)
+	workerCtx := ctx
+	if timeouts := taskTimeouts(task, wp.cfg); timeouts.RunMs > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, time.Duration(timeouts.RunMs)*time.Millisecond)
+		defer cancel()
+	}
+
+	vars := replayVars(task, run, original, wp.lastResult(dbCtx, task), wp.newSecretResolver(dbCtx), wp.clock)
+	outcome := wp.executeTarget(ctx, task, run, index, target, vars)
+
+	status, succeeded := "failed", 0
+	if outcome.success {
+		status, succeeded = "succeeded", 1
+	} else if workerCtx.Err() != nil {
+		status = "interrupted"
+	}
+	_, err := wp.db.FinishTaskRun(dbCtx, database.FinishTaskRunParams{
+		ID:               run.ID,
+		Status:           status,
+		FinishedAt:       pgtype.Timestamptz{Time: wp.clock.Now(), Valid: true},
+		TargetsSucceeded: int32(succeeded),
+	})
+	if err != nil {
+		log.Printf("Failed to finish replay of task %s: %v", task.Name, err)
+	}
+	if status == "failed" {
+		wp.deadLetter(dbCtx, task, run, index, outcome)
+	}
+
+	log.Printf("Replay of task %s target %d %s", task.Name, index, status)
+}
+
+// replayVars are the run variables of a replay. They carry the original
+// run's idempotency key, which for runs without a scheduled time is derived
+// from the run itself.
+func replayVars(task database.Task, run database.TaskRun, original database.TaskRun, prev *PrevResult, secrets *secretResolver, clk clock.Clock) RunVars {
+	vars := runVars(task, run, prev, secrets, clk)
+	if original.ID.Valid {
+		vars.IdempotencyKey = runVars(task, original, nil, nil, clk).IdempotencyKey
+	}
+	return vars
+}
@@ -0,0 +1,69 @@
+package workers
+
+import (
+	"context"
+	"errors"
+	"scheduler/clock"
+	"scheduler/database"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+func TestReplayKeepsIdempotencyKey(t *testing.T) {
+	clk := clock.New()
+	task := database.Task{ID: pgtype.UUID{Bytes: [16]byte{1}, Valid: true}}
+	replayRun := database.TaskRun{ID: pgtype.UUID{Bytes: [16]byte{9}, Valid: true}, RunNumber: 8}
+
+	scheduled := database.TaskRun{
+		ID:          pgtype.UUID{Bytes: [16]byte{2}, Valid: true},
+		ScheduledAt: pgtype.Timestamptz{Time: time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC), Valid: true},
+	}
+	manual := database.TaskRun{ID: pgtype.UUID{Bytes: [16]byte{3}, Valid: true}}
+
+	for name, original := range map[string]database.TaskRun{"scheduled": scheduled, "manual": manual} {
+		t.Run(name, func(t *testing.T) {
+			// A replay run carries the original's scheduled time.
+			run := replayRun
+			run.ScheduledAt = original.ScheduledAt
+
+			want := runVars(task, original, nil, nil, clk).IdempotencyKey
+			vars := replayVars(task, run, original, nil, nil, clk)
+			if vars.IdempotencyKey != want {
+				t.Errorf("replay key = %s, original %s", vars.IdempotencyKey, want)
+			}
+			if vars.RunID != uuidString(replayRun.ID.Bytes) || vars.Run != 8 {
+				t.Errorf("replay run vars = %s #%d", vars.RunID, vars.Run)
+			}
+		})
+	}
+
+	// Without the original run there is nothing to keep.
+	vars := replayVars(task, replayRun, database.TaskRun{}, nil, nil, clk)
+	if vars.IdempotencyKey != uuidString(replayRun.ID.Bytes) {
+		t.Errorf("key without original = %s", vars.IdempotencyKey)
+	}
+}
+
+func TestReplayRefusals(t *testing.T) {
+	wp := &WorkerPool{draining: make(chan struct{})}
+	task := database.Task{
+		ActionType:    DefaultActionType,
+		ActionMethod:  "POST",
+		ActionTargets: []byte(`[{"url":"https://a.example.com"},{"url":"https://b.example.com"}]`),
+	}
+
+	if _, err := wp.Replay(context.Background(), task, database.DeadLetter{TargetIndex: 2}); !errors.Is(err, ErrTargetRemoved) {
+		t.Errorf("removed target: Replay = %v, want ErrTargetRemoved", err)
+	}
+	if _, err := wp.Replay(context.Background(), task, database.DeadLetter{TargetIndex: 1}); !errors.Is(err, ErrShuttingDown) {
+		t.Errorf("pool not started: Replay = %v, want ErrShuttingDown", err)
+	}
+
+	wp.ctx = context.Background()
+	close(wp.draining)
+	if _, err := wp.Replay(context.Background(), task, database.DeadLetter{TargetIndex: 1}); !errors.Is(err, ErrShuttingDown) {
+		t.Errorf("draining: Replay = %v, want ErrShuttingDown", err)
+	}
+}
@@ -0,0 +1,424 @@
+package workers
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/tls"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	htmltemplate "html/template"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"net/textproto"
+	entity "scheduler/application/entity"
+	"scheduler/clock"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// SMTPConfig is the relay email actions are sent through.
+type SMTPConfig struct {
+	Addr     string
+	Username string
+	Password string
+	From     string
+}
+
+// EmailExecutor sends "email" actions through an SMTP relay and records the
+// relay's final reply as the result.
+type EmailExecutor struct {
+	cfg   SMTPConfig
+	clock clock.Clock
+}
+
+func NewEmailExecutor(cfg SMTPConfig, clk clock.Clock) *EmailExecutor {
+	return &EmailExecutor{cfg: cfg, clock: clk}
+}
+
+// emailVars are what email templates are executed with: the run variables
+// action templates see, plus the config's Data.
+type emailVars struct {
+	RunVars
+	Data map[string]interface{}
+}
+
+type renderedEmail struct {
+	subject string
+	text    string
+	html    string
+}
+
+func emailConfig(raw json.RawMessage) (entity.EmailConfig, error) {
+	var cfg entity.EmailConfig
+	if len(raw) == 0 {
+		return cfg, errors.New("config is required for email actions")
+	}
+	if err := json.Unmarshal(raw, &cfg); err != nil {
+		return cfg, fmt.Errorf("invalid email config: %w", err)
+	}
+	return cfg, nil
+}
+
+func (e *EmailExecutor) ValidateConfig(action entity.ActionData) error {
+	cfg, err := emailConfig(action.Config)
+	if err != nil {
+		return err
+	}
+	if err := e.check(cfg); err != nil {
+		return err
+	}
+	return checkEmailTemplates(cfg)
+}
+
+func (e *EmailExecutor) check(cfg entity.EmailConfig) error {
+	if cfg.From == "" && e.cfg.From == "" {
+		return errors.New("from is required when the server has no default sender")
+	}
+	if cfg.From == "" {
+		if _, err := mail.ParseAddress(e.cfg.From); err != nil {
+			return fmt.Errorf("invalid default sender: %w", err)
+		}
+	}
+	if cfg.From != "" {
+		if _, err := mail.ParseAddress(cfg.From); err != nil {
+			return fmt.Errorf("invalid from address: %w", err)
+		}
+	}
+	if cfg.ReplyTo != "" {
+		if _, err := mail.ParseAddress(cfg.ReplyTo); err != nil {
+			return fmt.Errorf("invalid reply_to address: %w", err)
+		}
+	}
+	if len(cfg.To)+len(cfg.Cc)+len(cfg.Bcc) == 0 {
+		return errors.New("at least one recipient is required")
+	}
+	for _, list := range [][]string{cfg.To, cfg.Cc, cfg.Bcc} {
+		for _, addr := range list {
+			if _, err := mail.ParseAddress(addr); err != nil {
+				return fmt.Errorf("invalid recipient %q: %w", addr, err)
+			}
+		}
+	}
+	if cfg.Subject == "" {
+		return errors.New("subject is required")
+	}
+	if cfg.Text == "" && cfg.HTML == "" {
+		return errors.New("text or html body is required")
+	}
+	return nil
+}
+
+func (e *EmailExecutor) Execute(ctx context.Context, job Execution) Result {
+	result := Result{Request: entity.DeadLetterRequest{Config: job.Config}}
+
+	cfg, err := emailConfig(job.Config)
+	if err == nil {
+		err = e.check(cfg)
+	}
+	if err != nil {
+		result.Err = requestError{err}
+		return result
+	}
+
+	rendered, err := renderEmail(cfg, job.Vars)
+	if err != nil {
+		result.Err = requestError{err}
+		return result
+	}
+
+	from := cfg.From
+	if from == "" {
+		from = e.cfg.From
+	}
+	msg, err := buildEmail(from, cfg, rendered, e.clock.Now())
+	if err != nil {
+		result.Err = requestError{err}
+		return result
+	}
+
+	var recipients []string
+	for _, list := range [][]string{cfg.To, cfg.Cc, cfg.Bcc} {
+		for _, addr := range list {
+			parsed, err := mail.ParseAddress(addr)
+			if err != nil {
+				result.Err = requestError{fmt.Errorf("invalid recipient %q: %w", addr, err)}
+				return result
+			}
+			recipients = append(recipients, parsed.Address)
+		}
+	}
+	sender, err := mail.ParseAddress(from)
+	if err != nil {
+		result.Err = requestError{fmt.Errorf("invalid from address: %w", err)}
+		return result
+	}
+
+	start := time.Now()
+	code, reply, err := e.send(ctx, sender.Address, recipients, msg)
+	result.Duration = time.Since(start)
+	result.StatusCode = int32(code)
+
+	var smtpErr *textproto.Error
+	if errors.As(err, &smtpErr) {
+		result.StatusCode = int32(smtpErr.Code)
+		reply = fmt.Sprintf("%d %s", smtpErr.Code, smtpErr.Msg)
+		// Permanent failures (5xx) won't succeed on a retry.
+		if smtpErr.Code >= 500 {
+			err = requestError{err}
+		}
+	}
+	if ctx.Err() != nil {
+		err = ctx.Err()
+	}
+	result.Err = err
+	result.Success = err == nil
+
+	if reply != "" {
+		result.Body, _ = captureBody(strings.NewReader(reply), "text/plain", job.Capture, job.NeedBody)
+	}
+	return result
+}
+
+// send delivers msg and returns the relay's reply to the end of DATA. The
+// DATA exchange is done by hand because smtp.Client discards that reply.
+func (e *EmailExecutor) send(ctx context.Context, from string, to []string, msg []byte) (int, string, error) {
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", e.cfg.Addr)
+	if err != nil {
+		return 0, "", err
+	}
+	defer conn.Close()
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+	stop := context.AfterFunc(ctx, func() { conn.Close() })
+	defer stop()
+
+	host, _, _ := net.SplitHostPort(e.cfg.Addr)
+	c, err := smtp.NewClient(conn, host)
+	if err != nil {
+		return 0, "", err
+	}
+	defer c.Close()
+
+	if ok, _ := c.Extension("STARTTLS"); ok {
+		if err := c.StartTLS(&tls.Config{ServerName: host}); err != nil {
+			return 0, "", err
+		}
+	}
+	if e.cfg.Username != "" {
+		if err := c.Auth(smtp.PlainAuth("", e.cfg.Username, e.cfg.Password, host)); err != nil {
+			return 0, "", err
+		}
+	}
+	if err := c.Mail(from); err != nil {
+		return 0, "", err
+	}
+	for _, rcpt := range to {
+		if err := c.Rcpt(rcpt); err != nil {
+			return 0, "", err
+		}
+	}
+
+	id, err := c.Text.Cmd("DATA")
+	if err != nil {
+		return 0, "", err
+	}
+	c.Text.StartResponse(id)
+	_, _, err = c.Text.ReadResponse(354)
+	c.Text.EndResponse(id)
+	if err != nil {
+		return 0, "", err
+	}
+
+	w := c.Text.DotWriter()
+	if _, err := w.Write(msg); err != nil {
+		return 0, "", err
+	}
+	if err := w.Close(); err != nil {
+		return 0, "", err
+	}
+	code, reply, err := c.Text.ReadResponse(250)
+	if err != nil {
+		return 0, "", err
+	}
+
+	c.Quit()
+	return code, fmt.Sprintf("%d %s", code, reply), nil
+}
+
+// renderEmail executes the subject, text and html templates with the same
+// helpers as action templates, "secret" included.
+func renderEmail(cfg entity.EmailConfig, vars RunVars) (renderedEmail, error) {
+	var rendered renderedEmail
+	data := emailVars{RunVars: vars, Data: cfg.Data}
+	funcs, done := vars.funcs()
+
+	subject, err := parseTemplate("subject", cfg.Subject, funcs)
+	if err != nil {
+		return rendered, fmt.Errorf("subject: %w", err)
+	}
+	var buf bytes.Buffer
+	if err := subject.Execute(&buf, data); err != nil {
+		return rendered, fmt.Errorf("subject: %w", err)
+	}
+	rendered.subject = strings.Join(strings.Fields(buf.String()), " ")
+	done(rendered.subject)
+
+	if cfg.Text != "" {
+		text, err := parseTemplate("text", cfg.Text, funcs)
+		if err != nil {
+			return rendered, fmt.Errorf("text: %w", err)
+		}
+		buf.Reset()
+		if err := text.Execute(&buf, data); err != nil {
+			return rendered, fmt.Errorf("text: %w", err)
+		}
+		rendered.text = buf.String()
+		done(rendered.text)
+	}
+
+	if cfg.HTML != "" {
+		html, err := parseHTMLTemplate("html", cfg.HTML, funcs)
+		if err != nil {
+			return rendered, fmt.Errorf("html: %w", err)
+		}
+		buf.Reset()
+		if err := html.Execute(&buf, data); err != nil {
+			return rendered, fmt.Errorf("html: %w", err)
+		}
+		rendered.html = buf.String()
+		done(rendered.html)
+	}
+
+	return rendered, nil
+}
+
+func parseHTMLTemplate(name, text string, funcs template.FuncMap) (*htmltemplate.Template, error) {
+	return htmltemplate.New(name).Option("missingkey=error").Funcs(htmltemplate.FuncMap(templateFuncs)).Funcs(htmltemplate.FuncMap(funcs)).Parse(text)
+}
+
+// checkEmailTemplates parses the templates so syntax errors are reported when
+// the task is saved. They aren't executed: secrets and run variables only
+// exist when the task runs.
+func checkEmailTemplates(cfg entity.EmailConfig) error {
+	if _, err := parseTemplate("subject", cfg.Subject, checkFuncs); err != nil {
+		return fmt.Errorf("subject: %w", err)
+	}
+	if _, err := parseTemplate("text", cfg.Text, checkFuncs); err != nil {
+		return fmt.Errorf("text: %w", err)
+	}
+	if _, err := parseHTMLTemplate("html", cfg.HTML, checkFuncs); err != nil {
+		return fmt.Errorf("html: %w", err)
+	}
+	return nil
+}
+
+func buildEmail(from string, cfg entity.EmailConfig, rendered renderedEmail, date time.Time) ([]byte, error) {
+	var msg bytes.Buffer
+
+	header := textproto.MIMEHeader{}
+	header.Set("From", formatAddresses([]string{from}))
+	header.Set("To", formatAddresses(cfg.To))
+	header.Set("Cc", formatAddresses(cfg.Cc))
+	if cfg.ReplyTo != "" {
+		header.Set("Reply-To", formatAddresses([]string{cfg.ReplyTo}))
+	}
+	header.Set("Subject", mime.QEncoding.Encode("utf-8", rendered.subject))
+	header.Set("Date", date.Format(time.RFC1123Z))
+	header.Set("Message-ID", messageID(from))
+	header.Set("MIME-Version", "1.0")
+
+	switch {
+	case rendered.text != "" && rendered.html != "":
+		parts := multipart.NewWriter(&msg)
+		header.Set("Content-Type", "multipart/alternative; boundary="+parts.Boundary())
+		writeHeader(&msg, header)
+		for _, part := range []struct{ contentType, body string }{
+			{"text/plain; charset=utf-8", rendered.text},
+			{"text/html; charset=utf-8", rendered.html},
+		} {
+			w, err := parts.CreatePart(textproto.MIMEHeader{
+				"Content-Type":              {part.contentType},
+				"Content-Transfer-Encoding": {"quoted-printable"},
+			})
+			if err != nil {
+				return nil, err
+			}
+			if err := writeQuotedPrintable(w, part.body); err != nil {
+				return nil, err
+			}
+		}
+		if err := parts.Close(); err != nil {
+			return nil, err
+		}
+
+	case rendered.html != "":
+		header.Set("Content-Type", "text/html; charset=utf-8")
+		header.Set("Content-Transfer-Encoding", "quoted-printable")
+		writeHeader(&msg, header)
+		if err := writeQuotedPrintable(&msg, rendered.html); err != nil {
+			return nil, err
+		}
+
+	default:
+		header.Set("Content-Type", "text/plain; charset=utf-8")
+		header.Set("Content-Transfer-Encoding", "quoted-printable")
+		writeHeader(&msg, header)
+		if err := writeQuotedPrintable(&msg, rendered.text); err != nil {
+			return nil, err
+		}
+	}
+
+	return msg.Bytes(), nil
+}
+
+// formatAddresses re-encodes addresses so display names outside ASCII are
+// valid in headers.
+func formatAddresses(addrs []string) string {
+	formatted := make([]string, 0, len(addrs))
+	for _, addr := range addrs {
+		if parsed, err := mail.ParseAddress(addr); err == nil {
+			formatted = append(formatted, parsed.String())
+		}
+	}
+	return strings.Join(formatted, ", ")
+}
+
+func writeHeader(buf *bytes.Buffer, header textproto.MIMEHeader) {
+	for _, name := range []string{"From", "To", "Cc", "Reply-To", "Subject", "Date", "Message-ID", "MIME-Version", "Content-Type", "Content-Transfer-Encoding"} {
+		if v := header.Get(name); v != "" {
+			fmt.Fprintf(buf, "%s: %s\r\n", name, v)
+		}
+	}
+	buf.WriteString("\r\n")
+}
+
+func writeQuotedPrintable(w io.Writer, body string) error {
+	qp := quotedprintable.NewWriter(w)
+	if _, err := qp.Write([]byte(body)); err != nil {
+		return err
+	}
+	return qp.Close()
+}
+
+func messageID(from string) string {
+	domain := "localhost"
+	if addr, err := mail.ParseAddress(from); err == nil {
+		if at := strings.LastIndex(addr.Address, "@"); at >= 0 {
+			domain = addr.Address[at+1:]
+		}
+	}
+	b := make([]byte, 12)
+	rand.Read(b)
+	return fmt.Sprintf("<%s@%s>", hex.EncodeToString(b), domain)
+}
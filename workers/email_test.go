@@ -0,0 +1,247 @@
+package workers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/textproto"
+	entity "scheduler/application/entity"
+	"scheduler/clock"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// smtpServer is a minimal SMTP relay that records what it receives. Mail to
+// rejected recipients gets a permanent failure at RCPT.
+type smtpServer struct {
+	addr     string
+	rejected string
+
+	mu   sync.Mutex
+	from string
+	to   []string
+	data string
+}
+
+func newSMTPServer(t *testing.T) *smtpServer {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+
+	s := &smtpServer{addr: ln.Addr().String()}
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go s.serve(conn)
+		}
+	}()
+	return s
+}
+
+func (s *smtpServer) serve(conn net.Conn) {
+	defer conn.Close()
+	text := textproto.NewConn(conn)
+	text.PrintfLine("220 localhost ESMTP test")
+	for {
+		line, err := text.ReadLine()
+		if err != nil {
+			return
+		}
+		verb, arg, _ := strings.Cut(line, " ")
+		switch strings.ToUpper(verb) {
+		case "EHLO", "HELO":
+			text.PrintfLine("250 localhost")
+		case "MAIL":
+			s.mu.Lock()
+			s.from = strings.Trim(strings.TrimPrefix(arg, "FROM:"), "<>")
+			s.mu.Unlock()
+			text.PrintfLine("250 2.1.0 OK")
+		case "RCPT":
+			rcpt := strings.Trim(strings.TrimPrefix(arg, "TO:"), "<>")
+			if rcpt == s.rejected {
+				text.PrintfLine("550 5.1.1 no such user")
+				continue
+			}
+			s.mu.Lock()
+			s.to = append(s.to, rcpt)
+			s.mu.Unlock()
+			text.PrintfLine("250 2.1.5 OK")
+		case "DATA":
+			text.PrintfLine("354 go ahead")
+			data, err := text.ReadDotBytes()
+			if err != nil {
+				return
+			}
+			s.mu.Lock()
+			s.data = string(data)
+			s.mu.Unlock()
+			text.PrintfLine("250 2.0.0 queued as 4F2A")
+		case "QUIT":
+			text.PrintfLine("221 2.0.0 bye")
+			return
+		default:
+			text.PrintfLine("502 5.5.2 unknown command")
+		}
+	}
+}
+
+func emailJob(t *testing.T, cfg entity.EmailConfig) Execution {
+	t.Helper()
+	raw, err := json.Marshal(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return Execution{
+		Config:  raw,
+		Attempt: 1,
+		Capture: entity.CaptureConfig{MaxBodyBytes: 1024},
+		Vars:    RunVars{Attempt: 1, now: clock.New().Now},
+	}
+}
+
+func TestEmailExecutorSends(t *testing.T) {
+	server := newSMTPServer(t)
+	clk := clock.NewFake(time.Date(2026, 3, 8, 10, 0, 0, 0, time.UTC))
+	executor := NewEmailExecutor(SMTPConfig{Addr: server.addr, From: "Scheduler <scheduler@example.com>"}, clk)
+
+	job := emailJob(t, entity.EmailConfig{
+		To:      []string{"Ops <ops@example.com>"},
+		Bcc:     []string{"audit@example.com"},
+		Subject: "Report for {{now | date \"2006-01-02\"}}",
+		Text:    "Attempt {{.Attempt}}",
+	})
+	job.Vars.now = clk.Now
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	result := executor.Execute(ctx, job)
+	if result.Err != nil || !result.Success {
+		t.Fatalf("Execute = %+v", result)
+	}
+	if result.StatusCode != 250 {
+		t.Errorf("status = %d, want 250", result.StatusCode)
+	}
+	if got := string(result.Body.raw); got != "250 2.0.0 queued as 4F2A" {
+		t.Errorf("reply = %q", got)
+	}
+
+	server.mu.Lock()
+	defer server.mu.Unlock()
+	if server.from != "scheduler@example.com" {
+		t.Errorf("envelope sender = %q", server.from)
+	}
+	if fmt.Sprint(server.to) != "[ops@example.com audit@example.com]" {
+		t.Errorf("recipients = %v", server.to)
+	}
+	for _, want := range []string{
+		"Subject: Report for 2026-03-08\n",
+		"Date: Sun, 08 Mar 2026 10:00:00 +0000\n",
+		"Attempt 1",
+	} {
+		if !strings.Contains(server.data, want) {
+			t.Errorf("message is missing %q:\n%s", want, server.data)
+		}
+	}
+	if strings.Contains(server.data, "audit@example.com") {
+		t.Error("bcc recipient is in the message headers")
+	}
+}
+
+func TestEmailExecutorPermanentFailure(t *testing.T) {
+	server := newSMTPServer(t)
+	server.rejected = "gone@example.com"
+	executor := NewEmailExecutor(SMTPConfig{Addr: server.addr, From: "scheduler@example.com"}, clock.New())
+
+	result := executor.Execute(context.Background(), emailJob(t, entity.EmailConfig{
+		To:      []string{"gone@example.com"},
+		Subject: "hello",
+		Text:    "hello",
+	}))
+	if result.Success || result.StatusCode != 550 {
+		t.Fatalf("Execute = %+v, want a 550 failure", result)
+	}
+	if errorClass(result.Err) != errorClassRequest {
+		t.Errorf("error class = %s, want %s so it isn't retried", errorClass(result.Err), errorClassRequest)
+	}
+}
+
+func TestEmailExecutorInvalidDefaultSender(t *testing.T) {
+	server := newSMTPServer(t)
+	executor := NewEmailExecutor(SMTPConfig{Addr: server.addr, From: "not an address"}, clock.New())
+	cfg := entity.EmailConfig{To: []string{"ops@example.com"}, Subject: "hello", Text: "hello"}
+
+	result := executor.Execute(context.Background(), emailJob(t, cfg))
+	if result.Err == nil || errorClass(result.Err) != errorClassRequest {
+		t.Fatalf("Execute error = %v, want a request error", result.Err)
+	}
+
+	raw, _ := json.Marshal(cfg)
+	if err := executor.ValidateConfig(entity.ActionData{Config: raw}); err == nil {
+		t.Error("ValidateConfig accepted a task relying on an invalid default sender")
+	}
+}
+
+func TestEmailTemplates(t *testing.T) {
+	server := newSMTPServer(t)
+	executor := NewEmailExecutor(SMTPConfig{Addr: server.addr, From: "scheduler@example.com"}, clock.New())
+	cfg := entity.EmailConfig{
+		To:      []string{"ops@example.com"},
+		Subject: "{{.TaskName}} run {{.Run}} for {{.Data.team}}",
+		Text:    "Token {{secret \"report-token\" | upper}}",
+		HTML:    "<p>{{.Data.note}}</p>",
+		Data:    map[string]interface{}{"team": "billing", "note": "<b>late</b>"},
+	}
+
+	raw, _ := json.Marshal(cfg)
+	if err := executor.ValidateConfig(entity.ActionData{Config: raw}); err != nil {
+		t.Fatalf("ValidateConfig = %v", err)
+	}
+	broken := cfg
+	broken.Text = "{{secret}"
+	raw, _ = json.Marshal(broken)
+	if err := executor.ValidateConfig(entity.ActionData{Config: raw}); err == nil {
+		t.Error("ValidateConfig accepted a malformed template")
+	}
+
+	secrets := testSecrets(t, map[string]string{"report-token": "abc"})
+	job := emailJob(t, cfg)
+	job.Vars.TaskName = "invoices"
+	job.Vars.Run = 4
+	job.Vars.secrets = secrets
+	result := executor.Execute(context.Background(), job)
+	if result.Err != nil || !result.Success {
+		t.Fatalf("Execute = %+v", result)
+	}
+
+	server.mu.Lock()
+	data := server.data
+	server.mu.Unlock()
+	for _, want := range []string{
+		"Subject: invoices run 4 for billing\n",
+		"Token ABC",
+		"<p>&lt;b&gt;late&lt;/b&gt;</p>",
+	} {
+		if !strings.Contains(data, want) {
+			t.Errorf("message is missing %q:\n%s", want, data)
+		}
+	}
+	// What was rendered from the secret is redacted wherever it's stored.
+	if got := secrets.redact("failed near Token ABC"); strings.Contains(got, "ABC") {
+		t.Errorf("redact = %q", got)
+	}
+
+	job.Vars.secrets = nil
+	result = executor.Execute(context.Background(), job)
+	if result.Err == nil || errorClass(result.Err) != errorClassRequest {
+		t.Errorf("Execute without secrets = %v, want a request error", result.Err)
+	}
+}
@@ -1,112 +1,282 @@
 package workers
 
 import (
-	"bytes"
 	"context"
 	"encoding/json"
-	"io"
+	"errors"
+	"fmt"
 	"log"
-	"net/http"
+	entity "scheduler/application/entity"
 	"scheduler/database"
+	"strings"
+	"sync"
 	"time"
 
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
-func buildReq(task database.Task) (*http.Request, error) {
-	var headers map[string]string
-	json.Unmarshal(task.ActionHeaders, &headers)
-	var payload []byte
-	json.Unmarshal(task.ActionPayload, &payload)
-
-	req, err := http.NewRequest(task.ActionMethod, task.ActionUrl, bytes.NewReader(payload))
-
-	if err != nil {
-		return nil, err
-	}
-
-	for k, v := range headers {
-		req.Header.Set(k, v)
-	}
-	return req, nil
-}
-
-func getResponse(req *http.Request) (*http.Response, time.Duration, error) {
-	client := &http.Client{}
-	start := time.Now()
-	resp, err := client.Do(req)
-	duration := time.Since(start)
-	return resp, duration, err
+type attemptResult struct {
+	success    bool
+	statusCode int32
+	errMessage string
+	errClass   string
 }
 
-func (wp *WorkerPool) saveResult(ctx context.Context, task database.Task, resp *http.Response, duration time.Duration, taskErr error) {
-	var statusCode int32
-	var success bool
+func (wp *WorkerPool) saveResult(ctx context.Context, task database.Task, run database.TaskRun, index int, attempt int, target entity.TargetData, result Result) attemptResult {
 	var responseHeaders json.RawMessage
-	var responseBody json.RawMessage
 	var errorMessage string
+	var errClass string
 
-	if taskErr != nil {
-		statusCode = 0
-		success = false
-		errorMessage = taskErr.Error()
-	} else {
-		statusCode = int32(resp.StatusCode)
-		success = resp.StatusCode >= 200 && resp.StatusCode < 300
-
-		hdrs, _ := json.Marshal(resp.Header)
-		responseHeaders = hdrs
+	criteria := taskSuccessCriteria(task)
+	success := result.Err == nil && statusAccepted(criteria, result)
 
-		rawBody, _ := io.ReadAll(resp.Body)
-		resp.Body.Close()
+	if header := captureHeaders(result.Header, taskCapture(task, wp.cfg)); header != nil {
+		responseHeaders, _ = json.Marshal(header)
+	}
 
-		var tmp interface{}
-		if err := json.Unmarshal(rawBody, &tmp); err != nil {
-			responseBody, _ = json.Marshal(string(rawBody))
-		} else {
-			responseBody = rawBody
-		}
+	var exitCode pgtype.Int4
+	if result.ExitCode != nil {
+		exitCode = pgtype.Int4{Int32: *result.ExitCode, Valid: true}
+	}
 
+	if result.Err != nil {
+		errorMessage = result.Err.Error()
+		errClass = errorClass(result.Err)
+	} else if !success {
+		errClass = errorClassStatus
+	} else if failures := checkResponse(criteria, result.Header, result.Body.raw, result.Duration); len(failures) > 0 {
+		success = false
+		errorMessage = "assertions failed: " + strings.Join(failures, "; ")
+		errClass = errorClassAssertion
 	}
 
-	_, dbErr := wp.db.CreateTaskResult(ctx, database.CreateTaskResultParams{
+	_, dbErr := wp.db.CreateTaskResult(context.WithoutCancel(ctx), database.CreateTaskResultParams{
 		TaskID:          task.ID,
-		RunAt:           pgtype.Timestamptz{Time: time.Now().UTC(), Valid: true},
-		StatusCode:      statusCode,
+		RunID:           run.ID,
+		TargetIndex:     int32(index),
+		TargetUrl:       target.URL,
+		Attempt:         int32(attempt),
+		RunAt:           pgtype.Timestamptz{Time: wp.clock.Now(), Valid: true},
+		StatusCode:      result.StatusCode,
 		Success:         success,
-		ResponseHeaders: []byte(string(responseHeaders)),
-		ResponseBody:    []byte(string(responseBody)),
+		ResponseHeaders: []byte(responseHeaders),
+		ResponseBody:    []byte(result.Body.stored),
+		BodyEncoding:    pgtype.Text{String: result.Body.encoding, Valid: result.Body.encoding != ""},
+		BodyTruncated:   result.Body.truncated,
 		ErrorMessage:    pgtype.Text{String: errorMessage, Valid: errorMessage != ""},
-		DurationMs:      int32(duration.Milliseconds()),
+		ErrorClass:      pgtype.Text{String: errClass, Valid: errClass != ""},
+		DurationMs:      int32(result.Duration.Milliseconds()),
+		ExitCode:        exitCode,
+		Stderr:          pgtype.Text{String: result.Stderr, Valid: result.Stderr != ""},
+		IdempotencyKey:  pgtype.Text{String: result.IdempotencyKey, Valid: result.IdempotencyKey != ""},
 	})
 
 	if dbErr != nil {
 		log.Printf("Failed to save task result for task %s: %v", task.Name, dbErr)
+	}
+
+	return attemptResult{
+		success:    success,
+		statusCode: result.StatusCode,
+		errMessage: errorMessage,
+		errClass:   errClass,
+	}
+}
+
+func (wp *WorkerPool) executeTarget(ctx context.Context, task database.Task, run database.TaskRun, index int, target entity.TargetData, vars RunVars) targetOutcome {
+	policy := taskRetryPolicy(task)
+	timeouts := taskTimeouts(task, wp.cfg)
+	criteria := taskSuccessCriteria(task)
+	outcome := targetOutcome{request: requestSnapshot(target)}
+
+	executor, ok := wp.executors.Get(task.ActionType)
+	if !ok {
+		outcome.attempts = 1
+		err := requestError{fmt.Errorf("unsupported action type %q", task.ActionType)}
+		log.Printf("Failed to execute task %s: %v", task.Name, err)
+		outcome.attemptResult = wp.saveResult(ctx, task, run, index, 1, target, Result{Err: err})
+		return outcome
+	}
+
+	vars.Target = index
+	for attempt := 1; ; attempt++ {
+		outcome.attempts = attempt
+
+		vars.Attempt = attempt
+		rendered, err := renderTarget(target, vars)
+		if err != nil {
+			// Template errors quote the values they failed on, which can
+			// be secrets.
+			_, result := vars.secrets.redactResult(target, Result{Err: templateError(err), Request: requestSnapshot(target)})
+			log.Printf("Failed to execute task %s: %v", task.Name, result.Err)
+			outcome.attemptResult = wp.saveResult(ctx, task, run, index, attempt, target, result)
+			return outcome
+		}
+
+		attemptCtx, cancel := context.WithTimeout(ctx, time.Duration(timeouts.TotalMs)*time.Millisecond)
+		result := executor.Execute(attemptCtx, Execution{
+			Task:           task,
+			Target:         rendered,
+			Config:         task.ActionConfig,
+			Attempt:        attempt,
+			Timeouts:       timeouts,
+			Capture:        taskCapture(task, wp.cfg),
+			NeedBody:       len(criteria.BodyAssertions) > 0,
+			IdempotencyKey: vars.IdempotencyKey,
+			Vars:           vars,
+		})
+		cancel()
+
+		rendered, result = vars.secrets.redactResult(rendered, result)
+		outcome.request = result.Request
+		outcome.attemptResult = wp.saveResult(ctx, task, run, index, attempt, rendered, result)
+
+		if outcome.success || !shouldRetry(policy, attempt, result) {
+			return outcome
+		}
+
+		delay, ok := retryDelay(policy, attempt, result, wp.clock.Now(), wp.cfg.MaxRetryAfter)
+		if !ok {
+			log.Printf("Not retrying task %s target %d: server asked to wait %v", task.Name, index, delay)
+			return outcome
+		}
+		log.Printf("Retrying task %s target %d in %v (attempt %d/%d)", task.Name, index, delay, attempt+1, policy.MaxAttempts)
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return outcome
+		case <-timer.C:
+		}
+	}
+}
+
+// lastResult is the task's most recent result for templates, nil when it has
+// none.
+func (wp *WorkerPool) lastResult(ctx context.Context, task database.Task) *PrevResult {
+	last, err := wp.db.GetLastTaskResult(ctx, task.ID)
+	if err != nil {
+		if !errors.Is(err, pgx.ErrNoRows) {
+			log.Printf("Failed to load previous result for task %s: %v", task.Name, err)
+		}
+		return nil
+	}
+	return prevResult(last)
+}
+
+func (wp *WorkerPool) executeTask(ctx context.Context, task database.Task) {
+	log.Printf("Executing task: %s [%s %s %s]", task.Name, task.ActionType, task.ActionMethod, task.ActionUrl)
+
+	targets, err := taskTargets(task)
+	if err != nil {
+		log.Printf("Failed to load targets for task %s: %v", task.Name, err)
 		return
 	}
 
-	_, err := wp.db.UpdateTaskStatus(ctx, database.UpdateTaskStatusParams{
-		ID:     task.ID,
-		Status: "completed",
+	// Results are recorded even when the run deadline passes or the worker
+	// is stopping, so bookkeeping uses a context that can't be cancelled.
+	dbCtx := context.WithoutCancel(ctx)
+	workerCtx := ctx
+	if timeouts := taskTimeouts(task, wp.cfg); timeouts.RunMs > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, time.Duration(timeouts.RunMs)*time.Millisecond)
+		defer cancel()
+	}
+
+	prev := wp.lastResult(dbCtx, task)
+
+	run, err := wp.db.CreateTaskRun(dbCtx, database.CreateTaskRunParams{
+		TaskID:       task.ID,
+		StartedAt:    pgtype.Timestamptz{Time: wp.clock.Now(), Valid: true},
+		SuccessRule:  task.ActionSuccessRule,
+		TargetsTotal: int32(len(targets)),
+		ScheduledAt:  task.NextRun,
 	})
 	if err != nil {
-		log.Printf("Failed to update task %s status: %v", task.Name, err)
+		log.Printf("Failed to create run for task %s: %v", task.Name, err)
 		return
 	}
+	vars := runVars(task, run, prev, wp.newSecretResolver(dbCtx), wp.clock)
 
-	log.Printf("Task %s completed (success=%v)", task.Name, success)
-}
+	results := make([]targetOutcome, len(targets))
+	if task.ActionMode == "sequential" {
+		for i, target := range targets {
+			results[i] = wp.executeTarget(ctx, task, run, i, target, vars)
+		}
+	} else {
+		var wg sync.WaitGroup
+		for i, target := range targets {
+			wg.Add(1)
+			go func(i int, target entity.TargetData) {
+				defer wg.Done()
+				results[i] = wp.executeTarget(ctx, task, run, i, target, vars)
+			}(i, target)
+		}
+		wg.Wait()
+	}
 
-func (wp *WorkerPool) executeTask(ctx context.Context, task database.Task) {
-	log.Printf("Executing task: %s [%s %s]", task.Name, task.ActionMethod, task.ActionUrl)
+	succeeded := 0
+	for _, outcome := range results {
+		if outcome.success {
+			succeeded++
+		}
+	}
+	success := successRuleMet(task.ActionSuccessRule, int(task.ActionQuorum), succeeded, len(targets))
 
-	req, err := buildReq(task)
+	// A run cut short by shutdown says nothing about the task, so it doesn't
+	// count as a failure.
+	interrupted := !success && workerCtx.Err() != nil
+
+	runStatus := "failed"
+	if success {
+		runStatus = "succeeded"
+	} else if interrupted {
+		runStatus = "interrupted"
+	}
+
+	_, err = wp.db.FinishTaskRun(dbCtx, database.FinishTaskRunParams{
+		ID:               run.ID,
+		Status:           runStatus,
+		FinishedAt:       pgtype.Timestamptz{Time: wp.clock.Now(), Valid: true},
+		TargetsSucceeded: int32(succeeded),
+	})
 	if err != nil {
-		log.Printf("Failed to build request for task %s: %v", task.Name, err)
-		wp.saveResult(ctx, task, nil, 0, err)
+		log.Printf("Failed to finish run for task %s: %v", task.Name, err)
+	}
+
+	if interrupted {
+		// Recurring tasks run again at their next occurrence; a one-off
+		// would never run again, so it goes back to its scheduled time.
+		if task.TriggerType == "one-off" {
+			wp.requeue(task)
+		}
+		log.Printf("Task %s interrupted (%d/%d targets succeeded)", task.Name, succeeded, len(targets))
 		return
 	}
-	resp, duration, err := getResponse(req)
 
-	wp.saveResult(ctx, task, resp, duration, err)
+	if success {
+		wp.resetFailures(dbCtx, task)
+	} else {
+		for i, outcome := range results {
+			if !outcome.success {
+				wp.deadLetter(dbCtx, task, run, i, outcome)
+			}
+		}
+		wp.recordFailure(dbCtx, task)
+	}
+
+	if task.TriggerType == "one-off" {
+		_, err = wp.db.UpdateTaskStatus(dbCtx, database.UpdateTaskStatusParams{
+			ID:     task.ID,
+			Status: "completed",
+		})
+		if err != nil {
+			log.Printf("Failed to update task %s status: %v", task.Name, err)
+			return
+		}
+	}
+
+	log.Printf("Task %s completed (success=%v, %d/%d targets succeeded)", task.Name, success, succeeded, len(targets))
 }
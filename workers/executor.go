@@ -0,0 +1,136 @@
+package workers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	entity "scheduler/application/entity"
+	"scheduler/database"
+	"sort"
+	"sync"
+	"time"
+)
+
+const DefaultActionType = "http"
+
+// Execution is a single attempt at running a task's action against one
+// target. Executors for non-HTTP action types read their settings from
+// Config; Target carries only the task's rendered headers and payload.
+type Execution struct {
+	Task     database.Task
+	Target   entity.TargetData
+	Config   json.RawMessage
+	Attempt  int
+	Timeouts entity.TimeoutConfig
+	Capture  entity.CaptureConfig
+	// NeedBody is set when assertions have to see the response body even
+	// when the task discards it. The body is still cut at the capture limit.
+	NeedBody bool
+	// IdempotencyKey is the same for every attempt at one scheduled
+	// occurrence. http actions send it in the configured header.
+	IdempotencyKey string
+
+	// Vars are the run's template variables, for executors that render
+	// templates in their own config. Their secrets resolve the secrets auth,
+	// signing and TLS settings refer to and remember them for redaction.
+	Vars RunVars
+}
+
+// Result is what an executor reports back for one attempt. StatusCode and
+// Header are matched against the task's success criteria and retry policy.
+// Executors without a natural status leave StatusCode at 0 and report
+// failures through Err, which makes them retryable like network errors.
+type Result struct {
+	StatusCode int32
+	Success    bool
+	Header     http.Header
+	Body       capturedBody
+	Duration   time.Duration
+	Err        error
+	// ExitCode and Stderr are only reported by executors that run processes.
+	ExitCode *int32
+	Stderr   string
+	// Request is what was sent, kept for the dead-letter queue.
+	Request entity.DeadLetterRequest
+	// IdempotencyKey is the key an http action sent, if any.
+	IdempotencyKey string
+}
+
+type Executor interface {
+	Execute(ctx context.Context, exec Execution) Result
+}
+
+// ConfigValidator is implemented by executors that can check an action's
+// config when a task is created or updated, before anything runs.
+type ConfigValidator interface {
+	ValidateConfig(action entity.ActionData) error
+}
+
+type Registry struct {
+	mu        sync.RWMutex
+	executors map[string]Executor
+}
+
+func NewRegistry() *Registry {
+	return &Registry{executors: make(map[string]Executor)}
+}
+
+func (r *Registry) Register(actionType string, executor Executor) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.executors[actionType] = executor
+}
+
+func (r *Registry) Get(actionType string) (Executor, bool) {
+	if actionType == "" {
+		actionType = DefaultActionType
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	executor, ok := r.executors[actionType]
+	return executor, ok
+}
+
+func (r *Registry) Types() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	types := make([]string, 0, len(r.executors))
+	for t := range r.executors {
+		types = append(types, t)
+	}
+	sort.Strings(types)
+	return types
+}
+
+// Validate checks that the action's type is registered, that its templates
+// parse and, when the executor supports it, that its config is usable.
+func (r *Registry) Validate(action entity.ActionData) error {
+	executor, ok := r.Get(action.Type)
+	if !ok {
+		return fmt.Errorf("unsupported action type %q (supported: %v)", action.Type, r.Types())
+	}
+	if err := CheckTemplates(action); err != nil {
+		return fmt.Errorf("invalid template: %w", err)
+	}
+	if action.Auth != nil {
+		if err := checkAuth(action.Type, *action.Auth); err != nil {
+			return fmt.Errorf("invalid auth: %w", err)
+		}
+	}
+	if action.Proxy != "" {
+		if action.Type != "" && action.Type != DefaultActionType {
+			return fmt.Errorf("proxy is only supported for %s actions", DefaultActionType)
+		}
+		if err := CheckProxy(action.Proxy); err != nil {
+			return fmt.Errorf("invalid proxy: %w", err)
+		}
+	}
+	if action.Type != "" && action.Type != DefaultActionType && len(action.Targets) > 0 {
+		return fmt.Errorf("targets are only supported for %s actions", DefaultActionType)
+	}
+	if v, ok := executor.(ConfigValidator); ok {
+		return v.ValidateConfig(action)
+	}
+	return nil
+}
@@ -0,0 +1,76 @@
+package workers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	entity "scheduler/application/entity"
+	"testing"
+)
+
+type stubExecutor struct{}
+
+func (stubExecutor) Execute(ctx context.Context, exec Execution) Result {
+	return Result{StatusCode: 200, Success: true}
+}
+
+func TestRegistryValidate(t *testing.T) {
+	r := NewRegistry()
+	r.Register(DefaultActionType, HTTPExecutor{})
+	r.Register("stub", stubExecutor{})
+
+	tests := []struct {
+		name    string
+		action  entity.ActionData
+		wantErr bool
+	}{
+		{"default type is http", entity.ActionData{Method: "GET", URL: "http://example.com"}, false},
+		{"http needs a method", entity.ActionData{Type: "http", URL: "http://example.com"}, true},
+		{"http needs a url", entity.ActionData{Type: "http", Method: "GET"}, true},
+		{"http targets stand in for the url", entity.ActionData{Method: "GET", Targets: []entity.TargetData{{URL: "http://example.com"}}}, false},
+		{"unknown type", entity.ActionData{Type: "ftp"}, true},
+		{"executor without validation", entity.ActionData{Type: "stub"}, false},
+		{"targets on other types", entity.ActionData{Type: "stub", Targets: []entity.TargetData{{URL: "http://example.com"}}}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := r.Validate(tt.action)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+
+	if got := r.Types(); len(got) != 2 || got[0] != "http" || got[1] != "stub" {
+		t.Errorf("Types = %v", got)
+	}
+}
+
+func TestHTTPExecutorReportsResponse(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain")
+		w.WriteHeader(http.StatusAccepted)
+		w.Write([]byte("queued"))
+	}))
+	defer server.Close()
+
+	result := testHTTPExecutor().Execute(context.Background(), Execution{
+		Target:   entity.TargetData{Method: http.MethodPost, URL: server.URL},
+		Timeouts: entity.TimeoutConfig{TotalMs: 5000},
+		Capture:  entity.CaptureConfig{MaxBodyBytes: 1024},
+		NeedBody: true,
+	})
+	if result.Err != nil {
+		t.Fatal(result.Err)
+	}
+	if result.StatusCode != http.StatusAccepted || !result.Success {
+		t.Errorf("status = %d, success = %v", result.StatusCode, result.Success)
+	}
+	if string(result.Body.raw) != "queued" {
+		t.Errorf("body = %q", result.Body.raw)
+	}
+	if result.Request.Method != http.MethodPost || result.Request.URL != server.URL {
+		t.Errorf("request snapshot = %+v", result.Request)
+	}
+}
@@ -0,0 +1,70 @@
+package workers
+
+import (
+	"encoding/json"
+	entity "scheduler/application/entity"
+	"scheduler/database"
+)
+
+func taskTargets(task database.Task) ([]entity.TargetData, error) {
+	var headers map[string]string
+	json.Unmarshal(task.ActionHeaders, &headers)
+
+	var payload interface{}
+	if len(task.ActionPayload) > 0 && string(task.ActionPayload) != "null" {
+		payload = json.RawMessage(task.ActionPayload)
+	}
+
+	// Only HTTP actions fan out; other executors run once from their config,
+	// with the task's headers and payload rendered like any target's.
+	if task.ActionType != "" && task.ActionType != DefaultActionType {
+		return []entity.TargetData{{Headers: headers, Payload: payload}}, nil
+	}
+
+	var targets []entity.TargetData
+	if task.ActionTargets != nil {
+		if err := json.Unmarshal(task.ActionTargets, &targets); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(targets) == 0 {
+		return []entity.TargetData{{
+			Method:  task.ActionMethod,
+			URL:     task.ActionUrl,
+			Headers: headers,
+			Payload: payload,
+		}}, nil
+	}
+
+	for i := range targets {
+		if targets[i].Method == "" {
+			targets[i].Method = task.ActionMethod
+		}
+		if targets[i].Payload == nil {
+			targets[i].Payload = payload
+		}
+
+		merged := make(map[string]string, len(headers)+len(targets[i].Headers))
+		for k, v := range headers {
+			merged[k] = v
+		}
+		for k, v := range targets[i].Headers {
+			merged[k] = v
+		}
+		targets[i].Headers = merged
+	}
+
+	return targets, nil
+}
+
+func successRuleMet(rule string, quorum int, succeeded int, total int) bool {
+	switch rule {
+	case "any":
+		return succeeded > 0
+	case "quorum":
+		return succeeded >= quorum
+	default:
+		return succeeded == total
+	}
+}
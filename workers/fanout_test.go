@@ -0,0 +1,76 @@
+package workers
+
+import (
+	"encoding/json"
+	"scheduler/database"
+	"testing"
+)
+
+func TestTaskTargets(t *testing.T) {
+	task := database.Task{
+		ActionMethod:  "POST",
+		ActionUrl:     "https://example.com/hook",
+		ActionHeaders: []byte(`{"X-Team":"ops","X-Region":"eu"}`),
+		ActionPayload: []byte(`{"id":1}`),
+	}
+
+	targets, err := taskTargets(task)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(targets) != 1 || targets[0].URL != task.ActionUrl || targets[0].Method != "POST" || targets[0].Headers["X-Team"] != "ops" {
+		t.Fatalf("single target = %+v", targets)
+	}
+
+	task.ActionTargets = []byte(`[
+		{"url":"https://a.example.com"},
+		{"url":"https://b.example.com","method":"PUT","headers":{"X-Region":"us"},"payload":{"id":2}}
+	]`)
+	targets, err = taskTargets(task)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(targets) != 2 {
+		t.Fatalf("got %d targets, want 2", len(targets))
+	}
+
+	a, b := targets[0], targets[1]
+	if a.Method != "POST" || a.Headers["X-Region"] != "eu" || a.Headers["X-Team"] != "ops" {
+		t.Errorf("target a = %+v, want the task's method and headers", a)
+	}
+	if b.Method != "PUT" || b.Headers["X-Region"] != "us" || b.Headers["X-Team"] != "ops" {
+		t.Errorf("target b = %+v, want its own method and header merged over the task's", b)
+	}
+	payloadA, _ := json.Marshal(a.Payload)
+	payloadB, _ := json.Marshal(b.Payload)
+	if string(payloadA) != `{"id":1}` || string(payloadB) != `{"id":2}` {
+		t.Errorf("payloads = %s, %s", payloadA, payloadB)
+	}
+
+	task.ActionTargets = []byte(`{"url":"not a list"}`)
+	if _, err := taskTargets(task); err == nil {
+		t.Error("taskTargets accepted malformed targets")
+	}
+}
+
+func TestSuccessRuleMet(t *testing.T) {
+	tests := []struct {
+		rule      string
+		quorum    int
+		succeeded int
+		want      bool
+	}{
+		{"all", 0, 3, true},
+		{"all", 0, 2, false},
+		{"", 0, 3, true},
+		{"any", 0, 1, true},
+		{"any", 0, 0, false},
+		{"quorum", 2, 2, true},
+		{"quorum", 2, 1, false},
+	}
+	for _, tt := range tests {
+		if got := successRuleMet(tt.rule, tt.quorum, tt.succeeded, 3); got != tt.want {
+			t.Errorf("successRuleMet(%q, %d, %d of 3) = %v, want %v", tt.rule, tt.quorum, tt.succeeded, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,381 @@
+package workers
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	entity "scheduler/application/entity"
+	"scheduler/database"
+	"strings"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	reflectionpb "google.golang.org/grpc/reflection/grpc_reflection_v1"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+// GRPCExecutor invokes a unary method for "grpc" actions. Request and
+// response messages are built at run time from descriptors, so no generated
+// code is needed for the target service.
+type GRPCExecutor struct {
+	db *database.Queries
+
+	mu    sync.Mutex
+	cache map[string]cachedDescriptorSet
+}
+
+type cachedDescriptorSet struct {
+	updatedAt time.Time
+	files     *protoregistry.Files
+}
+
+func NewGRPCExecutor(db *database.Queries) *GRPCExecutor {
+	return &GRPCExecutor{db: db, cache: make(map[string]cachedDescriptorSet)}
+}
+
+func grpcConfig(raw json.RawMessage) (entity.GRPCConfig, error) {
+	var cfg entity.GRPCConfig
+	if len(raw) == 0 {
+		return cfg, errors.New("config is required for grpc actions")
+	}
+	if err := json.Unmarshal(raw, &cfg); err != nil {
+		return cfg, fmt.Errorf("invalid grpc config: %w", err)
+	}
+	return cfg, nil
+}
+
+// splitMethod accepts "pkg.Service/Method", "/pkg.Service/Method" and
+// "pkg.Service.Method".
+func splitMethod(name string) (protoreflect.FullName, protoreflect.Name, error) {
+	name = strings.TrimPrefix(name, "/")
+	i := strings.LastIndexAny(name, "/.")
+	if i <= 0 || i == len(name)-1 {
+		return "", "", fmt.Errorf("invalid method %q, expected package.Service/Method", name)
+	}
+	service := protoreflect.FullName(name[:i])
+	method := protoreflect.Name(name[i+1:])
+	if !service.IsValid() || !method.IsValid() {
+		return "", "", fmt.Errorf("invalid method %q, expected package.Service/Method", name)
+	}
+	return service, method, nil
+}
+
+// ParseDescriptorSet checks a serialized FileDescriptorSet, as written by
+// protoc --include_imports --descriptor_set_out, and lists its services.
+func ParseDescriptorSet(data []byte) (*protoregistry.Files, []string, error) {
+	var set descriptorpb.FileDescriptorSet
+	if err := proto.Unmarshal(data, &set); err != nil {
+		return nil, nil, fmt.Errorf("invalid descriptor set: %w", err)
+	}
+	files, err := protodesc.NewFiles(&set)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid descriptor set: %w", err)
+	}
+
+	var services []string
+	files.RangeFiles(func(fd protoreflect.FileDescriptor) bool {
+		for i := 0; i < fd.Services().Len(); i++ {
+			services = append(services, string(fd.Services().Get(i).FullName()))
+		}
+		return true
+	})
+	return files, services, nil
+}
+
+func (e *GRPCExecutor) ValidateConfig(action entity.ActionData) error {
+	cfg, err := grpcConfig(action.Config)
+	if err != nil {
+		return err
+	}
+	if err := checkGRPCConfig(cfg); err != nil {
+		return err
+	}
+	if cfg.DescriptorSet == "" {
+		return nil
+	}
+
+	files, err := e.descriptorSet(context.Background(), cfg.DescriptorSet)
+	if err != nil {
+		return err
+	}
+	method, err := findMethod(files, cfg.Method)
+	if err != nil {
+		return err
+	}
+	_, err = requestMessage(method, files, cfg.Request)
+	return err
+}
+
+func checkGRPCConfig(cfg entity.GRPCConfig) error {
+	if cfg.Target == "" {
+		return errors.New("target is required for grpc actions")
+	}
+	if _, _, err := splitMethod(cfg.Method); err != nil {
+		return err
+	}
+	if len(cfg.Request) > 0 && !json.Valid(cfg.Request) {
+		return errors.New("request must be valid JSON")
+	}
+	if cfg.TLS != nil && cfg.TLS.CACert != "" {
+		if !x509.NewCertPool().AppendCertsFromPEM([]byte(cfg.TLS.CACert)) {
+			return errors.New("tls.ca_cert contains no valid certificates")
+		}
+	}
+	return nil
+}
+
+func (e *GRPCExecutor) Execute(ctx context.Context, job Execution) Result {
+	result := Result{Request: entity.DeadLetterRequest{Config: job.Config}}
+
+	cfg, err := grpcConfig(job.Config)
+	if err == nil {
+		err = checkGRPCConfig(cfg)
+	}
+	if err != nil {
+		result.Err = requestError{err}
+		return result
+	}
+
+	conn, err := grpc.NewClient(cfg.Target, grpc.WithTransportCredentials(grpcCredentials(cfg.TLS)))
+	if err != nil {
+		result.Err = requestError{err}
+		return result
+	}
+	defer conn.Close()
+
+	start := time.Now()
+
+	var files *protoregistry.Files
+	if cfg.DescriptorSet != "" {
+		files, err = e.descriptorSet(ctx, cfg.DescriptorSet)
+	} else {
+		files, err = reflectFiles(ctx, conn, cfg.Method)
+		if err != nil {
+			err = fmt.Errorf("server reflection: %w", err)
+		}
+	}
+	if err != nil {
+		result.Duration = time.Since(start)
+		result.Err = grpcError(ctx, err)
+		return result
+	}
+
+	method, err := findMethod(files, cfg.Method)
+	if err == nil && (method.IsStreamingClient() || method.IsStreamingServer()) {
+		err = fmt.Errorf("%s is a streaming method, only unary methods are supported", method.FullName())
+	}
+	var req *dynamicpb.Message
+	if err == nil {
+		req, err = requestMessage(method, files, cfg.Request)
+	}
+	if err != nil {
+		result.Err = requestError{err}
+		return result
+	}
+
+	md := metadata.New(cfg.Metadata)
+	callCtx := metadata.NewOutgoingContext(ctx, md)
+	resp := dynamicpb.NewMessage(method.Output())
+	var header, trailer metadata.MD
+
+	fullMethod := fmt.Sprintf("/%s/%s", method.Parent().FullName(), method.Name())
+	err = conn.Invoke(callCtx, fullMethod, req, resp, grpc.Header(&header), grpc.Trailer(&trailer))
+	result.Duration = time.Since(start)
+
+	result.Header = http.Header{}
+	for k, v := range header {
+		result.Header[k] = v
+	}
+	for k, v := range trailer {
+		result.Header[k] = append(result.Header[k], v...)
+	}
+
+	st := status.Convert(err)
+	result.StatusCode = int32(st.Code())
+	if err != nil {
+		result.Err = grpcError(ctx, err)
+		return result
+	}
+
+	body, err := protojson.MarshalOptions{Resolver: dynamicpb.NewTypes(files)}.Marshal(resp)
+	if err != nil {
+		result.Err = fmt.Errorf("encoding response: %w", err)
+		return result
+	}
+	result.Body, _ = captureBody(bytes.NewReader(body), "application/json", job.Capture, job.NeedBody)
+	result.Success = true
+
+	return result
+}
+
+func grpcCredentials(cfg *entity.GRPCTLSConfig) credentials.TransportCredentials {
+	if cfg == nil {
+		return insecure.NewCredentials()
+	}
+	tlsConfig := &tls.Config{
+		ServerName:         cfg.ServerName,
+		InsecureSkipVerify: cfg.InsecureSkipVerify,
+	}
+	if cfg.CACert != "" {
+		pool := x509.NewCertPool()
+		pool.AppendCertsFromPEM([]byte(cfg.CACert))
+		tlsConfig.RootCAs = pool
+	}
+	return credentials.NewTLS(tlsConfig)
+}
+
+// grpcError leaves transient failures retryable and marks the rest, like
+// unknown methods or invalid arguments, as request errors.
+func grpcError(ctx context.Context, err error) error {
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	st, ok := status.FromError(err)
+	if !ok {
+		return err
+	}
+	switch st.Code() {
+	case codes.Unavailable, codes.ResourceExhausted, codes.Aborted, codes.Internal, codes.Unknown:
+		return err
+	case codes.DeadlineExceeded:
+		return fmt.Errorf("%w: %s", context.DeadlineExceeded, st.Message())
+	}
+	return requestError{err}
+}
+
+func findMethod(files *protoregistry.Files, name string) (protoreflect.MethodDescriptor, error) {
+	serviceName, methodName, err := splitMethod(name)
+	if err != nil {
+		return nil, err
+	}
+	desc, err := files.FindDescriptorByName(serviceName)
+	if err != nil {
+		return nil, fmt.Errorf("service %s not found", serviceName)
+	}
+	service, ok := desc.(protoreflect.ServiceDescriptor)
+	if !ok {
+		return nil, fmt.Errorf("%s is not a service", serviceName)
+	}
+	method := service.Methods().ByName(methodName)
+	if method == nil {
+		return nil, fmt.Errorf("method %s not found in %s", methodName, serviceName)
+	}
+	return method, nil
+}
+
+func requestMessage(method protoreflect.MethodDescriptor, files *protoregistry.Files, request json.RawMessage) (*dynamicpb.Message, error) {
+	msg := dynamicpb.NewMessage(method.Input())
+	if len(request) == 0 {
+		return msg, nil
+	}
+	opts := protojson.UnmarshalOptions{Resolver: dynamicpb.NewTypes(files)}
+	if err := opts.Unmarshal(request, msg); err != nil {
+		return nil, fmt.Errorf("invalid request for %s: %w", method.Input().FullName(), err)
+	}
+	return msg, nil
+}
+
+func (e *GRPCExecutor) descriptorSet(ctx context.Context, name string) (*protoregistry.Files, error) {
+	set, err := e.db.GetDescriptorSet(ctx, name)
+	if err != nil {
+		return nil, requestError{fmt.Errorf("descriptor set %q not found", name)}
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if cached, ok := e.cache[name]; ok && cached.updatedAt.Equal(set.UpdatedAt.Time) {
+		return cached.files, nil
+	}
+
+	files, _, err := ParseDescriptorSet(set.Data)
+	if err != nil {
+		return nil, requestError{err}
+	}
+	e.cache[name] = cachedDescriptorSet{updatedAt: set.UpdatedAt.Time, files: files}
+	return files, nil
+}
+
+// reflectFiles asks the server's reflection service for the file defining
+// the method's service and, transitively, the files it imports.
+func reflectFiles(ctx context.Context, conn *grpc.ClientConn, method string) (*protoregistry.Files, error) {
+	serviceName, _, err := splitMethod(method)
+	if err != nil {
+		return nil, err
+	}
+
+	stream, err := reflectionpb.NewServerReflectionClient(conn).ServerReflectionInfo(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer stream.CloseSend()
+
+	fetch := func(req *reflectionpb.ServerReflectionRequest) ([][]byte, error) {
+		if err := stream.Send(req); err != nil {
+			return nil, err
+		}
+		resp, err := stream.Recv()
+		if err != nil {
+			return nil, err
+		}
+		if errResp := resp.GetErrorResponse(); errResp != nil {
+			return nil, status.Error(codes.Code(errResp.GetErrorCode()), errResp.GetErrorMessage())
+		}
+		return resp.GetFileDescriptorResponse().GetFileDescriptorProto(), nil
+	}
+
+	pending, err := fetch(&reflectionpb.ServerReflectionRequest{
+		MessageRequest: &reflectionpb.ServerReflectionRequest_FileContainingSymbol{FileContainingSymbol: string(serviceName)},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	set := &descriptorpb.FileDescriptorSet{}
+	seen := make(map[string]bool)
+	for len(pending) > 0 {
+		raw := pending[0]
+		pending = pending[1:]
+
+		fd := &descriptorpb.FileDescriptorProto{}
+		if err := proto.Unmarshal(raw, fd); err != nil {
+			return nil, fmt.Errorf("invalid descriptor from reflection: %w", err)
+		}
+		if seen[fd.GetName()] {
+			continue
+		}
+		seen[fd.GetName()] = true
+		set.File = append(set.File, fd)
+
+		for _, dep := range fd.GetDependency() {
+			if seen[dep] {
+				continue
+			}
+			more, err := fetch(&reflectionpb.ServerReflectionRequest{
+				MessageRequest: &reflectionpb.ServerReflectionRequest_FileByFilename{FileByFilename: dep},
+			})
+			if err != nil {
+				return nil, err
+			}
+			pending = append(pending, more...)
+		}
+	}
+
+	return protodesc.NewFiles(set)
+}
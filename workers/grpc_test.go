@@ -0,0 +1,130 @@
+package workers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+func TestSplitMethod(t *testing.T) {
+	tests := []struct {
+		name        string
+		wantService string
+		wantMethod  string
+		wantErr     bool
+	}{
+		{"pkg.Service/Method", "pkg.Service", "Method", false},
+		{"/pkg.Service/Method", "pkg.Service", "Method", false},
+		{"pkg.Service.Method", "pkg.Service", "Method", false},
+		{"Method", "", "", true},
+		{"pkg.Service/", "", "", true},
+		{"pkg.Service/Me-thod", "", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service, method, err := splitMethod(tt.name)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("splitMethod error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if string(service) != tt.wantService || string(method) != tt.wantMethod {
+				t.Errorf("splitMethod = %s, %s", service, method)
+			}
+		})
+	}
+}
+
+func TestGRPCErrorClass(t *testing.T) {
+	ctx := context.Background()
+	tests := []struct {
+		code codes.Code
+		want string
+	}{
+		{codes.Unavailable, errorClassNetwork},
+		{codes.InvalidArgument, errorClassRequest},
+		{codes.NotFound, errorClassRequest},
+		{codes.DeadlineExceeded, errorClassTimeout},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.code.String(), func(t *testing.T) {
+			err := grpcError(ctx, status.Error(tt.code, "boom"))
+			if got := errorClass(err); got != tt.want {
+				t.Errorf("errorClass = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	if err := grpcError(cancelled, status.Error(codes.InvalidArgument, "boom")); !errors.Is(err, context.Canceled) {
+		t.Errorf("grpcError after cancel = %v", err)
+	}
+}
+
+func TestDescriptorSetRequest(t *testing.T) {
+	set := &descriptorpb.FileDescriptorSet{File: []*descriptorpb.FileDescriptorProto{{
+		Name:    proto.String("greeter.proto"),
+		Package: proto.String("demo"),
+		Syntax:  proto.String("proto3"),
+		MessageType: []*descriptorpb.DescriptorProto{{
+			Name: proto.String("HelloRequest"),
+			Field: []*descriptorpb.FieldDescriptorProto{{
+				Name:     proto.String("name"),
+				JsonName: proto.String("name"),
+				Number:   proto.Int32(1),
+				Label:    descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum(),
+				Type:     descriptorpb.FieldDescriptorProto_TYPE_STRING.Enum(),
+			}},
+		}},
+		Service: []*descriptorpb.ServiceDescriptorProto{{
+			Name: proto.String("Greeter"),
+			Method: []*descriptorpb.MethodDescriptorProto{{
+				Name:       proto.String("SayHello"),
+				InputType:  proto.String(".demo.HelloRequest"),
+				OutputType: proto.String(".demo.HelloRequest"),
+			}},
+		}},
+	}}}
+	data, err := proto.Marshal(set)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	files, services, err := ParseDescriptorSet(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(services) != 1 || services[0] != "demo.Greeter" {
+		t.Errorf("services = %v", services)
+	}
+
+	method, err := findMethod(files, "demo.Greeter/SayHello")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := findMethod(files, "demo.Greeter/SayGoodbye"); err == nil {
+		t.Error("found a method the service doesn't have")
+	}
+
+	msg, err := requestMessage(method, files, json.RawMessage(`{"name":"ops"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := msg.Get(method.Input().Fields().ByName("name")).String(); got != "ops" {
+		t.Errorf("name = %q", got)
+	}
+	if _, err := requestMessage(method, files, json.RawMessage(`{"nope":1}`)); err == nil {
+		t.Error("accepted a request with an unknown field")
+	}
+
+	if _, _, err := ParseDescriptorSet([]byte("not a descriptor set")); err == nil {
+		t.Error("parsed garbage as a descriptor set")
+	}
+}
@@ -0,0 +1,156 @@
+package workers
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	entity "scheduler/application/entity"
+	"scheduler/clock"
+	"scheduler/database"
+	"time"
+)
+
+// HTTPExecutor sends the action as an HTTP request. It is registered for the
+// default action type, and applies the settings only http actions have: the
+// idempotency key header, auth, signing and TLS.
+type HTTPExecutor struct {
+	db                *database.Queries
+	clock             clock.Clock
+	transports        *transportPool
+	breakers          *breakerSet
+	tokens            *tokenCache
+	idempotencyHeader string
+}
+
+func (e HTTPExecutor) ValidateConfig(action entity.ActionData) error {
+	if action.Method == "" {
+		return errors.New("method is required for http actions")
+	}
+	if action.URL == "" && len(action.Targets) == 0 {
+		return errors.New("url or targets are required for http actions")
+	}
+	return nil
+}
+
+func (e HTTPExecutor) Execute(ctx context.Context, exec Execution) (result Result) {
+	target := exec.Target
+	if e.idempotencyHeader != "" && exec.IdempotencyKey != "" {
+		target, result.IdempotencyKey = withIdempotencyKey(target, e.idempotencyHeader, exec.IdempotencyKey)
+	}
+	result.Request = requestSnapshot(target)
+
+	var tlsConfig *tls.Config
+	if cfg := taskTLS(exec.Task); cfg != nil {
+		var err error
+		if tlsConfig, err = e.tlsConfig(ctx, *cfg, exec.Vars.secrets); err != nil {
+			result.Err = err
+			return result
+		}
+	}
+
+	var tokenKey string
+	if auth := taskAuth(exec.Task); auth != nil {
+		var err error
+		target, tokenKey, err = e.authorize(ctx, target, *auth, exec.Vars.secrets, exec.Timeouts, tlsConfig, exec.Task.ActionProxy.String)
+		if err != nil {
+			result.Err = err
+			return result
+		}
+	}
+	if signing := taskSigning(exec.Task); signing != nil {
+		var err error
+		if target, err = e.sign(target, *signing, exec.Vars.secrets); err != nil {
+			result.Err = err
+			return result
+		}
+	}
+	result.Request = requestSnapshot(target)
+
+	req, err := buildReq(ctx, target)
+	if err != nil {
+		result.Err = err
+		return result
+	}
+
+	host := req.URL.Host
+	if err := e.breakers.allow(host); err != nil {
+		result.Err = err
+		return result
+	}
+	defer func() {
+		e.breakers.record(host, result)
+	}()
+
+	client, err := e.transports.client(exec.Timeouts, tlsConfig, exec.Task.ActionProxy.String)
+	if err != nil {
+		result.Err = err
+		return result
+	}
+
+	resp, duration, err := getResponse(client, req)
+	result.Duration = duration
+	if err != nil {
+		result.Err = err
+		return result
+	}
+	defer drainBody(resp.Body)
+
+	result.StatusCode = int32(resp.StatusCode)
+	result.Success = resp.StatusCode >= 200 && resp.StatusCode < 300
+	result.Header = resp.Header
+
+	if tokenKey != "" && resp.StatusCode == http.StatusUnauthorized {
+		// The next attempt fetches a fresh token.
+		e.tokens.invalidate(tokenKey)
+	}
+
+	body, err := captureBody(resp.Body, resp.Header.Get("Content-Type"), exec.Capture, exec.NeedBody)
+	if err != nil {
+		result.Err = fmt.Errorf("reading response body: %w", err)
+	}
+	result.Body = body
+
+	return result
+}
+
+// targetBody is the request body sent for target, nil when it has no payload.
+func targetBody(target entity.TargetData) ([]byte, error) {
+	if target.Payload == nil {
+		return nil, nil
+	}
+	return json.Marshal(target.Payload)
+}
+
+func buildReq(ctx context.Context, target entity.TargetData) (*http.Request, error) {
+	var body io.Reader = http.NoBody
+	payload, err := targetBody(target)
+	if err != nil {
+		return nil, requestError{err}
+	}
+	if payload != nil {
+		body = bytes.NewReader(payload)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, target.Method, target.URL, body)
+
+	if err != nil {
+		return nil, requestError{err}
+	}
+
+	for k, v := range target.Headers {
+		req.Header.Set(k, v)
+	}
+	return req, nil
+}
+
+func getResponse(client *http.Client, req *http.Request) (*http.Response, time.Duration, error) {
+	start := time.Now()
+	resp, err := client.Do(req)
+	duration := time.Since(start)
+	return resp, duration, err
+}
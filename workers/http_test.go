@@ -0,0 +1,154 @@
+package workers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	entity "scheduler/application/entity"
+	"scheduler/clock"
+	"scheduler/database"
+	"scheduler/signature"
+	"sync/atomic"
+	"testing"
+)
+
+func testHTTPExecutor() HTTPExecutor {
+	return HTTPExecutor{
+		clock:             clock.New(),
+		transports:        newTransportPool(DefaultTransportConfig()),
+		breakers:          newBreakerSet(DefaultBreakerConfig(), clock.New().Now),
+		tokens:            newTokenCache(),
+		idempotencyHeader: "Idempotency-Key",
+	}
+}
+
+func TestHTTPExecutorAppliesHTTPSettings(t *testing.T) {
+	var got http.Header
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.Header.Clone()
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	exec := Execution{
+		Target:         entity.TargetData{Method: http.MethodPost, URL: server.URL, Payload: map[string]interface{}{"a": 1}},
+		Timeouts:       entity.TimeoutConfig{TotalMs: 5000},
+		Capture:        entity.CaptureConfig{MaxBodyBytes: 1024},
+		IdempotencyKey: "key-1",
+		Vars:           RunVars{secrets: testSecrets(t, map[string]string{"pw": "hunter2", "hmac": "signing-key"})},
+	}
+	exec.Task.ActionAuth = []byte(`{"type":"basic","username":"u","password_ref":"pw"}`)
+	exec.Task.Signing = []byte(`{"secret":"hmac"}`)
+
+	result := testHTTPExecutor().Execute(context.Background(), exec)
+	if result.Err != nil || result.StatusCode != http.StatusNoContent {
+		t.Fatalf("Execute = %+v", result)
+	}
+
+	if got.Get("Idempotency-Key") != "key-1" || result.IdempotencyKey != "key-1" {
+		t.Errorf("idempotency key sent %q, recorded %q", got.Get("Idempotency-Key"), result.IdempotencyKey)
+	}
+	if got.Get("Authorization") != "Basic dTpodW50ZXIy" {
+		t.Errorf("Authorization = %q", got.Get("Authorization"))
+	}
+	if got.Get("X-Scheduler-Signature") == "" || got.Get("X-Scheduler-Timestamp") == "" {
+		t.Errorf("request was not signed: %v", got)
+	}
+	// The snapshot is what was sent, before redaction.
+	for _, name := range []string{"Idempotency-Key", "Authorization", "X-Scheduler-Signature"} {
+		if result.Request.Headers[name] != got.Get(name) {
+			t.Errorf("snapshot %s = %q, sent %q", name, result.Request.Headers[name], got.Get(name))
+		}
+	}
+}
+
+func TestHTTPExecutorSignsRenderedURL(t *testing.T) {
+	var verr error
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		verr = signature.Verifier{Key: []byte("signing-key")}.VerifyRequest(r, "")
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	task := database.Task{Signing: []byte(`{"secret":"hmac"}`)}
+	secrets := testSecrets(t, map[string]string{"hmac": "signing-key"})
+	target, err := renderTarget(entity.TargetData{
+		Method:  http.MethodPost,
+		URL:     server.URL + "/runs/{{.Run}}",
+		Payload: map[string]interface{}{"run": "{{.Run}}"},
+	}, runVars(task, database.TaskRun{RunNumber: 3}, nil, secrets, clock.New()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	result := testHTTPExecutor().Execute(context.Background(), Execution{
+		Task:     task,
+		Target:   target,
+		Timeouts: entity.TimeoutConfig{TotalMs: 5000},
+		Capture:  entity.CaptureConfig{MaxBodyBytes: 1024},
+		Vars:     RunVars{secrets: secrets},
+	})
+	if result.Err != nil || result.StatusCode != http.StatusNoContent {
+		t.Fatalf("Execute = %+v", result)
+	}
+	if verr != nil {
+		t.Errorf("receiver could not verify the signature: %v", verr)
+	}
+	if result.Request.Headers[signature.DefaultSignatureHeader] == "" {
+		t.Errorf("snapshot is missing the signature: %v", result.Request.Headers)
+	}
+}
+
+func TestHTTPExecutorKeepsTaskIdempotencyKey(t *testing.T) {
+	var got string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.Header.Get("Idempotency-Key")
+	}))
+	defer server.Close()
+
+	exec := Execution{
+		Target:         entity.TargetData{Method: http.MethodGet, URL: server.URL, Headers: map[string]string{"idempotency-key": "mine"}},
+		Timeouts:       entity.TimeoutConfig{TotalMs: 5000},
+		IdempotencyKey: "key-1",
+	}
+	result := testHTTPExecutor().Execute(context.Background(), exec)
+	if result.Err != nil {
+		t.Fatal(result.Err)
+	}
+	if got != "mine" || result.IdempotencyKey != "mine" {
+		t.Errorf("sent %q, recorded %q; want the task's own key", got, result.IdempotencyKey)
+	}
+}
+
+func TestHTTPExecutorDropsRejectedToken(t *testing.T) {
+	var fetches, calls atomic.Int32
+	tokens := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fetches.Add(1)
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"access_token":"tok","expires_in":300}`))
+	}))
+	defer tokens.Close()
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) == 1 {
+			w.WriteHeader(http.StatusUnauthorized)
+		}
+	}))
+	defer api.Close()
+
+	executor := testHTTPExecutor()
+	exec := Execution{
+		Target:   entity.TargetData{Method: http.MethodGet, URL: api.URL},
+		Timeouts: entity.TimeoutConfig{TotalMs: 5000},
+		Vars:     RunVars{secrets: testSecrets(t, map[string]string{"client": "s3cret"})},
+	}
+	exec.Task.ActionAuth = []byte(`{"type":"oauth2","token_url":"` + tokens.URL + `","client_id":"c","client_secret_ref":"client"}`)
+
+	for _, want := range []int32{http.StatusUnauthorized, http.StatusOK, http.StatusOK} {
+		if result := executor.Execute(context.Background(), exec); result.StatusCode != want {
+			t.Fatalf("status = %d, want %d (%v)", result.StatusCode, want, result.Err)
+		}
+	}
+	if n := fetches.Load(); n != 2 {
+		t.Errorf("fetched %d tokens, want 2: one before and one after the 401", n)
+	}
+}
@@ -0,0 +1,41 @@
+package workers
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	entity "scheduler/application/entity"
+	"strings"
+	"time"
+)
+
+// occurrenceKey derives a UUID from the task and the occurrence it was
+// scheduled for, so a run that is retried, requeued or dispatched twice
+// sends the same key.
+func occurrenceKey(taskID [16]byte, scheduledAt time.Time) string {
+	h := sha256.New()
+	h.Write(taskID[:])
+	binary.Write(h, binary.BigEndian, scheduledAt.UnixNano())
+	var b [16]byte
+	copy(b[:], h.Sum(nil))
+	// Version 5 layout, as name-based UUIDs are.
+	b[6] = b[6]&0x0f | 0x50
+	b[8] = b[8]&0x3f | 0x80
+	return uuidString(b)
+}
+
+// withIdempotencyKey adds the key header to target unless the task already
+// sets it, and returns the value that will be sent.
+func withIdempotencyKey(target entity.TargetData, header, key string) (entity.TargetData, string) {
+	for k, v := range target.Headers {
+		if strings.EqualFold(k, header) {
+			return target, v
+		}
+	}
+	headers := make(map[string]string, len(target.Headers)+1)
+	for k, v := range target.Headers {
+		headers[k] = v
+	}
+	headers[header] = key
+	target.Headers = headers
+	return target, key
+}
@@ -3,38 +3,169 @@ package workers
 import (
 	"context"
 	"log"
+	"scheduler/clock"
 	"scheduler/database"
+	"scheduler/secrets"
 	"sync"
+	"time"
 )
 
 type WorkerPool struct {
-	db       *database.Queries
-	taskChan <-chan database.Task
-	count    int
-	wg       *sync.WaitGroup
+	db         *database.Queries
+	clock      clock.Clock
+	taskChan   <-chan database.Task
+	cfg        Config
+	executors  *Registry
+	transports *transportPool
+	breakers   *breakerSet
+	wg         *sync.WaitGroup
+
+	// draining is closed by Shutdown. Workers stop taking tasks and the
+	// pool stops growing.
+	draining chan struct{}
+
+	mu      sync.Mutex
+	ctx     context.Context
+	cancel  context.CancelFunc
+	min     int
+	max     int
+	nextID  int
+	workers map[int]*workerState
+	// maxWait is the longest a task waited past its scheduled time since
+	// the last scaling decision.
+	maxWait time.Duration
 }
 
-func NewWorkerPool(db *database.Queries, taskChan <-chan database.Task, workerCount int) *WorkerPool {
+func NewWorkerPool(db *database.Queries, clk clock.Clock, taskChan <-chan database.Task, cfg Config) *WorkerPool {
+	transports := newTransportPool(cfg.Transport)
+	breakers := newBreakerSet(cfg.Breaker, clk.Now)
+	executors := NewRegistry()
+	executors.Register(DefaultActionType, HTTPExecutor{
+		db:                db,
+		clock:             clk,
+		transports:        transports,
+		breakers:          breakers,
+		tokens:            newTokenCache(),
+		idempotencyHeader: cfg.IdempotencyHeader,
+	})
+
 	return &WorkerPool{
-		db:       db,
-		taskChan: taskChan,
-		count:    workerCount,
-		wg:       &sync.WaitGroup{},
+		db:         db,
+		clock:      clk,
+		taskChan:   taskChan,
+		cfg:        cfg,
+		executors:  executors,
+		transports: transports,
+		breakers:   breakers,
+		wg:         &sync.WaitGroup{},
+		min:        cfg.MinWorkers,
+		max:        cfg.MaxWorkers,
+		workers:    make(map[int]*workerState),
+		draining:   make(chan struct{}),
 	}
 }
 
 func (wp *WorkerPool) Start(ctx context.Context) {
-	log.Printf("starting %d workers", wp.count)
+	wp.mu.Lock()
+	log.Printf("starting %d workers (max %d)", wp.min, wp.max)
+	wp.ctx, wp.cancel = context.WithCancel(ctx)
+	wp.spawnLocked(wp.min)
+	wp.mu.Unlock()
 
-	for i := 1; i <= wp.count; i++ {
+	if wp.cfg.ScaleInterval > 0 {
 		wp.wg.Add(1)
-		go wp.worker(ctx, i)
+		go wp.autoscale(wp.ctx)
 	}
+}
+
+// Size is the number of workers currently taking tasks.
+func (wp *WorkerPool) Size() int {
+	wp.mu.Lock()
+	defer wp.mu.Unlock()
+	return wp.activeLocked()
+}
 
+// Executors returns the registry used to look up the executor for each
+// task's action type. Register additional types before calling Start.
+func (wp *WorkerPool) Executors() *Registry {
+	return wp.executors
 }
 
-func (wp *WorkerPool) Stop() {
-	log.Println("Waiting for workers to finish...")
-	wp.wg.Wait()
+// Secrets returns the box secret values are encrypted with, or nil when no
+// master key is configured.
+func (wp *WorkerPool) Secrets() *secrets.Box {
+	return wp.cfg.Secrets
+}
+
+// Shutdown stops the workers from taking new tasks, puts queued tasks back so
+// they run after a restart, and waits for runs in progress. Runs still going
+// when ctx is done are cancelled and recorded as interrupted.
+func (wp *WorkerPool) Shutdown(ctx context.Context) error {
+	wp.mu.Lock()
+	select {
+	case <-wp.draining:
+	default:
+		close(wp.draining)
+	}
+	wp.mu.Unlock()
+
+	log.Println("Draining workers...")
+	for drained := false; !drained; {
+		select {
+		case task := <-wp.taskChan:
+			wp.requeue(task)
+		default:
+			drained = true
+		}
+	}
+
+	done := make(chan struct{})
+	go func() {
+		wp.wg.Wait()
+		close(done)
+	}()
+
+	var err error
+	select {
+	case <-done:
+	case <-ctx.Done():
+		log.Printf("Grace period over, interrupting %d running tasks", wp.Status().Busy)
+		wp.mu.Lock()
+		if wp.cancel != nil {
+			wp.cancel()
+		}
+		wp.mu.Unlock()
+		<-done
+		err = ctx.Err()
+	}
+
+	wp.transports.closeIdle()
 	log.Println("All workers stopped")
+	return err
+}
+
+func (wp *WorkerPool) isDraining() bool {
+	select {
+	case <-wp.draining:
+		return true
+	default:
+		return false
+	}
+}
+
+// requeue puts a task that was queued but never run back to its scheduled
+// time, unless it was rescheduled meanwhile.
+func (wp *WorkerPool) requeue(task database.Task) {
+	if !task.NextRun.Valid {
+		return
+	}
+	err := wp.db.RestoreTaskNextRun(context.Background(), database.RestoreTaskNextRunParams{
+		ID:      task.ID,
+		NextRun: task.NextRun,
+	})
+	if err != nil {
+		log.Printf("Failed to requeue task %s: %v", task.Name, err)
+		return
+	}
+	log.Printf("Requeued task %s for %s", task.Name, task.NextRun.Time.Format(time.RFC3339))
 }
@@ -0,0 +1,316 @@
+package workers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	entity "scheduler/application/entity"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+// BrokerConfig holds the brokers publish actions can reach. Either may be
+// empty, in which case actions for that broker are rejected.
+type BrokerConfig struct {
+	NATSURL string
+	AMQPURL string
+}
+
+// PublishExecutor publishes "publish" actions to NATS or AMQP and records the
+// broker's acknowledgement as the result body. Connections are opened on
+// first use and shared by all tasks.
+type PublishExecutor struct {
+	cfg BrokerConfig
+
+	mu   sync.Mutex
+	nc   *nats.Conn
+	amqp *amqp.Connection
+}
+
+func NewPublishExecutor(cfg BrokerConfig) *PublishExecutor {
+	return &PublishExecutor{cfg: cfg}
+}
+
+type publishAck struct {
+	Broker     string `json:"broker"`
+	Subject    string `json:"subject,omitempty"`
+	Stream     string `json:"stream,omitempty"`
+	Sequence   uint64 `json:"sequence,omitempty"`
+	Duplicate  bool   `json:"duplicate,omitempty"`
+	Exchange   string `json:"exchange,omitempty"`
+	RoutingKey string `json:"routing_key,omitempty"`
+	// DeliveryTag is the channel-local tag the AMQP broker confirmed.
+	DeliveryTag uint64 `json:"delivery_tag,omitempty"`
+}
+
+func publishConfig(raw json.RawMessage) (entity.PublishConfig, error) {
+	var cfg entity.PublishConfig
+	if len(raw) == 0 {
+		return cfg, errors.New("config is required for publish actions")
+	}
+	if err := json.Unmarshal(raw, &cfg); err != nil {
+		return cfg, fmt.Errorf("invalid publish config: %w", err)
+	}
+	return cfg, nil
+}
+
+func (e *PublishExecutor) ValidateConfig(action entity.ActionData) error {
+	cfg, err := publishConfig(action.Config)
+	if err != nil {
+		return err
+	}
+	return e.check(cfg)
+}
+
+func (e *PublishExecutor) check(cfg entity.PublishConfig) error {
+	switch cfg.Broker {
+	case "nats":
+		if e.cfg.NATSURL == "" {
+			return errors.New("no NATS server is configured")
+		}
+		if cfg.Subject == "" {
+			return errors.New("subject is required for nats")
+		}
+		if strings.ContainsAny(cfg.Subject, "*> \t") {
+			return fmt.Errorf("invalid subject %q: wildcards and whitespace are not allowed", cfg.Subject)
+		}
+	case "amqp":
+		if e.cfg.AMQPURL == "" {
+			return errors.New("no AMQP broker is configured")
+		}
+		if cfg.Exchange == "" && cfg.RoutingKey == "" {
+			return errors.New("exchange or routing_key is required for amqp")
+		}
+	default:
+		return fmt.Errorf("broker must be nats or amqp, got %q", cfg.Broker)
+	}
+	return nil
+}
+
+func (e *PublishExecutor) Execute(ctx context.Context, job Execution) Result {
+	result := Result{Request: entity.DeadLetterRequest{Config: job.Config}}
+
+	cfg, err := publishConfig(job.Config)
+	if err == nil {
+		err = e.check(cfg)
+	}
+	if err != nil {
+		result.Err = requestError{err}
+		return result
+	}
+
+	headers := make(map[string]string, len(job.Target.Headers)+len(cfg.Headers))
+	for k, v := range job.Target.Headers {
+		headers[k] = v
+	}
+	for k, v := range cfg.Headers {
+		headers[k] = v
+	}
+	var body []byte
+	if job.Target.Payload != nil {
+		if body, err = json.Marshal(job.Target.Payload); err != nil {
+			result.Err = requestError{err}
+			return result
+		}
+	}
+	result.Request.Headers = headers
+	result.Request.Body = body
+
+	var ack publishAck
+	start := time.Now()
+	switch cfg.Broker {
+	case "nats":
+		ack, err = e.publishNATS(ctx, cfg, headers, body)
+	case "amqp":
+		ack, err = e.publishAMQP(ctx, cfg, headers, body)
+	}
+	result.Duration = time.Since(start)
+	if ctx.Err() != nil {
+		err = ctx.Err()
+	}
+	var returned amqpReturnError
+	if errors.As(err, &returned) {
+		result.StatusCode = int32(returned.ReplyCode)
+	}
+	if err != nil {
+		result.Err = err
+		return result
+	}
+
+	encoded, _ := json.Marshal(ack)
+	result.Body, _ = captureBody(bytes.NewReader(encoded), "application/json", job.Capture, job.NeedBody)
+	result.Success = true
+	return result
+}
+
+func (e *PublishExecutor) natsConn() (*nats.Conn, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.nc != nil && !e.nc.IsClosed() {
+		return e.nc, nil
+	}
+	nc, err := nats.Connect(e.cfg.NATSURL, nats.Name("scheduler"), nats.MaxReconnects(-1))
+	if err != nil {
+		return nil, err
+	}
+	e.nc = nc
+	return nc, nil
+}
+
+func (e *PublishExecutor) publishNATS(ctx context.Context, cfg entity.PublishConfig, headers map[string]string, body []byte) (publishAck, error) {
+	ack := publishAck{Broker: "nats", Subject: cfg.Subject}
+
+	nc, err := e.natsConn()
+	if err != nil {
+		return ack, err
+	}
+
+	msg := nats.NewMsg(cfg.Subject)
+	msg.Data = body
+	for k, v := range headers {
+		msg.Header.Set(k, v)
+	}
+
+	if cfg.JetStream {
+		js, err := jetstream.New(nc)
+		if err != nil {
+			return ack, err
+		}
+		pubAck, err := js.PublishMsg(ctx, msg)
+		if errors.Is(err, jetstream.ErrNoStreamResponse) {
+			return ack, requestError{fmt.Errorf("no stream captures subject %q", cfg.Subject)}
+		}
+		if err != nil {
+			return ack, err
+		}
+		ack.Stream = pubAck.Stream
+		ack.Sequence = pubAck.Sequence
+		ack.Duplicate = pubAck.Duplicate
+		return ack, nil
+	}
+
+	if len(headers) > 0 && !nc.HeadersSupported() {
+		return ack, requestError{errors.New("the NATS server does not support headers")}
+	}
+	if err := nc.PublishMsg(msg); err != nil {
+		return ack, err
+	}
+	// Core NATS has no per-message ack; a flush round trip confirms the
+	// server has received everything published before it.
+	return ack, nc.FlushWithContext(ctx)
+}
+
+// amqpReturnError is a mandatory message the broker couldn't route.
+type amqpReturnError struct {
+	amqp.Return
+}
+
+func (e amqpReturnError) Error() string {
+	return fmt.Sprintf("message returned by broker: %d %s", e.ReplyCode, e.ReplyText)
+}
+
+func (e *PublishExecutor) amqpConn() (*amqp.Connection, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.amqp != nil && !e.amqp.IsClosed() {
+		return e.amqp, nil
+	}
+	conn, err := amqp.Dial(e.cfg.AMQPURL)
+	if err != nil {
+		return nil, err
+	}
+	e.amqp = conn
+	return conn, nil
+}
+
+func (e *PublishExecutor) publishAMQP(ctx context.Context, cfg entity.PublishConfig, headers map[string]string, body []byte) (publishAck, error) {
+	ack := publishAck{Broker: "amqp", Exchange: cfg.Exchange, RoutingKey: cfg.RoutingKey}
+
+	conn, err := e.amqpConn()
+	if err != nil {
+		return ack, err
+	}
+	// Channels are cheap and a channel error closes the channel, so each
+	// publish gets its own.
+	ch, err := conn.Channel()
+	if err != nil {
+		return ack, err
+	}
+	defer ch.Close()
+
+	if err := ch.Confirm(false); err != nil {
+		return ack, err
+	}
+	closed := ch.NotifyClose(make(chan *amqp.Error, 1))
+	returns := ch.NotifyReturn(make(chan amqp.Return, 1))
+
+	table := amqp.Table{}
+	for k, v := range headers {
+		table[k] = v
+	}
+	msg := amqp.Publishing{
+		Headers:     table,
+		ContentType: cfg.ContentType,
+		Body:        body,
+		Timestamp:   time.Now(),
+	}
+	if msg.ContentType == "" && body != nil {
+		msg.ContentType = "application/json"
+	}
+	if cfg.Persistent {
+		msg.DeliveryMode = amqp.Persistent
+	}
+
+	confirm, err := ch.PublishWithDeferredConfirmWithContext(ctx, cfg.Exchange, cfg.RoutingKey, cfg.Mandatory, false, msg)
+	if err != nil {
+		return ack, err
+	}
+	acked, err := confirm.WaitContext(ctx)
+	if err != nil {
+		return ack, err
+	}
+	ack.DeliveryTag = confirm.DeliveryTag
+
+	// The broker sends basic.return before the ack of an unroutable
+	// mandatory message, so it is already queued here.
+	select {
+	case ret := <-returns:
+		return ack, requestError{amqpReturnError{ret}}
+	default:
+	}
+	if !acked {
+		select {
+		case amqpErr := <-closed:
+			if amqpErr != nil && amqpErr.Code >= 400 && amqpErr.Code < 500 {
+				// Missing exchanges and refused access are channel errors
+				// that fail the same way on every attempt.
+				return ack, requestError{amqpErr}
+			}
+			if amqpErr != nil {
+				return ack, amqpErr
+			}
+		default:
+		}
+		return ack, errors.New("message was nacked by the broker")
+	}
+	return ack, nil
+}
+
+// Close drops the broker connections, flushing anything NATS still buffers.
+func (e *PublishExecutor) Close() {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.nc != nil {
+		e.nc.Drain()
+	}
+	if e.amqp != nil {
+		e.amqp.Close()
+	}
+}
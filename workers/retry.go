@@ -0,0 +1,133 @@
+package workers
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"math/rand"
+	"net/http"
+	entity "scheduler/application/entity"
+	"scheduler/database"
+	"slices"
+	"strconv"
+	"time"
+)
+
+var defaultRetryOnStatus = []int{
+	http.StatusRequestTimeout,
+	http.StatusTooManyRequests,
+	http.StatusInternalServerError,
+	http.StatusBadGateway,
+	http.StatusServiceUnavailable,
+	http.StatusGatewayTimeout,
+}
+
+func taskRetryPolicy(task database.Task) entity.RetryPolicy {
+	policy := entity.RetryPolicy{MaxAttempts: 1}
+	if task.RetryPolicy != nil {
+		json.Unmarshal(task.RetryPolicy, &policy)
+	}
+
+	if policy.MaxAttempts < 1 {
+		policy.MaxAttempts = 1
+	}
+	if policy.InitialDelayMs == 0 {
+		policy.InitialDelayMs = 1000
+	}
+	if policy.Multiplier < 1 {
+		policy.Multiplier = 2
+	}
+	if len(policy.RetryOnStatus) == 0 {
+		policy.RetryOnStatus = defaultRetryOnStatus
+	}
+	if policy.RetryOnNetworkError == nil {
+		// A statement whose connection dropped may still have committed,
+		// so sql actions only run it again when the task asks for that.
+		retry := task.ActionType != "sql"
+		policy.RetryOnNetworkError = &retry
+	}
+
+	return policy
+}
+
+// abortedError is a failure the remote end reported before the attempt had
+// any effect, so it is safe to retry even when network errors aren't.
+type abortedError struct {
+	err error
+}
+
+func (e abortedError) Error() string {
+	return e.err.Error()
+}
+
+func (e abortedError) Unwrap() error {
+	return e.err
+}
+
+func shouldRetry(policy entity.RetryPolicy, attempt int, result Result) bool {
+	if attempt >= policy.MaxAttempts {
+		return false
+	}
+	if result.Err != nil {
+		// An open circuit fails fast rather than holding the worker
+		// through backoff.
+		switch errorClass(result.Err) {
+		case errorClassRequest, errorClassCircuit:
+			return false
+		}
+		var aborted abortedError
+		if errors.As(result.Err, &aborted) {
+			return true
+		}
+		return *policy.RetryOnNetworkError
+	}
+	return slices.Contains(policy.RetryOnStatus, int(result.StatusCode))
+}
+
+// retryDelay returns how long to wait before the attempt following the given
+// one. Jitter spreads the backoff before it is capped at MaxDelayMs. A
+// Retry-After header on 429 or 503 responses takes precedence when it asks
+// for a longer wait than the backoff would, up to MaxDelayMs or, when the
+// policy has none, maxRetryAfter. The boolean is false when the server asks
+// for a longer wait than that; the target is not retried then.
+func retryDelay(policy entity.RetryPolicy, attempt int, result Result, now time.Time, maxRetryAfter time.Duration) (time.Duration, bool) {
+	delay := float64(policy.InitialDelayMs) * math.Pow(policy.Multiplier, float64(attempt-1))
+	if policy.Jitter > 0 {
+		delay += delay * policy.Jitter * (2*rand.Float64() - 1)
+	}
+	if policy.MaxDelayMs > 0 && delay > float64(policy.MaxDelayMs) {
+		delay = float64(policy.MaxDelayMs)
+	}
+	backoff := time.Duration(delay) * time.Millisecond
+
+	if result.StatusCode == http.StatusTooManyRequests || result.StatusCode == http.StatusServiceUnavailable {
+		if retryAfter, ok := parseRetryAfter(result.Header.Get("Retry-After"), now); ok && retryAfter > backoff {
+			limit := maxRetryAfter
+			if policy.MaxDelayMs > 0 {
+				limit = time.Duration(policy.MaxDelayMs) * time.Millisecond
+			}
+			if limit > 0 && retryAfter > limit {
+				return retryAfter, false
+			}
+			return retryAfter, true
+		}
+	}
+
+	return backoff, true
+}
+
+func parseRetryAfter(value string, now time.Time) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+	if at, err := http.ParseTime(value); err == nil {
+		if d := at.Sub(now); d > 0 {
+			return d, true
+		}
+		return 0, true
+	}
+	return 0, false
+}
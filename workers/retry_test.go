@@ -0,0 +1,62 @@
+package workers
+
+import (
+	"net/http"
+	entity "scheduler/application/entity"
+	"testing"
+	"time"
+)
+
+func TestRetryDelayJitterStaysUnderMax(t *testing.T) {
+	policy := entity.RetryPolicy{InitialDelayMs: 1000, Multiplier: 2, MaxDelayMs: 5000, Jitter: 0.5}
+	now := time.Now()
+
+	for i := 0; i < 1000; i++ {
+		delay, ok := retryDelay(policy, 10, Result{StatusCode: http.StatusBadGateway}, now, time.Minute)
+		if !ok {
+			t.Fatal("backoff was refused")
+		}
+		if delay > 5*time.Second {
+			t.Fatalf("delay %v is above max_delay_ms", delay)
+		}
+	}
+
+	for i := 0; i < 1000; i++ {
+		delay, _ := retryDelay(policy, 1, Result{StatusCode: http.StatusBadGateway}, now, time.Minute)
+		if delay < 500*time.Millisecond || delay > 1500*time.Millisecond {
+			t.Fatalf("delay %v is outside the jitter range", delay)
+		}
+	}
+}
+
+func TestRetryDelayRetryAfter(t *testing.T) {
+	now := time.Date(2026, 3, 8, 10, 0, 0, 0, time.UTC)
+	backoff := entity.RetryPolicy{InitialDelayMs: 1000, Multiplier: 2}
+	capped := entity.RetryPolicy{InitialDelayMs: 1000, Multiplier: 2, MaxDelayMs: 30000}
+
+	tests := []struct {
+		name       string
+		policy     entity.RetryPolicy
+		status     int
+		retryAfter string
+		want       time.Duration
+		wantOK     bool
+	}{
+		{"longer wait is honoured", capped, http.StatusTooManyRequests, "20", 20 * time.Second, true},
+		{"shorter wait keeps the backoff", capped, http.StatusServiceUnavailable, "0", time.Second, true},
+		{"http date", capped, http.StatusServiceUnavailable, now.Add(25 * time.Second).Format(http.TimeFormat), 25 * time.Second, true},
+		{"above max_delay_ms gives up", capped, http.StatusTooManyRequests, "31", 31 * time.Second, false},
+		{"above the server limit gives up", backoff, http.StatusTooManyRequests, "3600", time.Hour, false},
+		{"ignored on other statuses", capped, http.StatusBadGateway, "3600", time.Second, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := Result{StatusCode: int32(tt.status), Header: http.Header{"Retry-After": {tt.retryAfter}}}
+			got, ok := retryDelay(tt.policy, 1, result, now, 5*time.Minute)
+			if got != tt.want || ok != tt.wantOK {
+				t.Errorf("retryDelay = %v, %v; want %v, %v", got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
@@ -0,0 +1,169 @@
+package workers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	entity "scheduler/application/entity"
+	"scheduler/database"
+	"scheduler/secrets"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/jackc/pgx/v5"
+)
+
+const redacted = "[REDACTED]"
+
+// secretResolver looks up the secrets a run's templates reference and
+// remembers their values so they can be scrubbed from everything stored
+// about the run.
+type secretResolver struct {
+	ctx context.Context
+	db  *database.Queries
+	box *secrets.Box
+
+	mu     sync.Mutex
+	values map[string]string
+}
+
+func (wp *WorkerPool) newSecretResolver(ctx context.Context) *secretResolver {
+	return &secretResolver{ctx: ctx, db: wp.db, box: wp.cfg.Secrets, values: map[string]string{}}
+}
+
+func (r *secretResolver) resolve(name string) (string, error) {
+	if r == nil || r.box == nil {
+		return "", errors.New("secrets are not configured")
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if value, ok := r.values[name]; ok {
+		return value, nil
+	}
+
+	secret, err := r.db.GetSecret(r.ctx, name)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return "", fmt.Errorf("secret %q not found", name)
+	}
+	if err != nil {
+		return "", fmt.Errorf("loading secret %q: %w", name, err)
+	}
+	value, err := r.box.Open(name, secret.Value)
+	if err != nil {
+		return "", err
+	}
+	r.values[name] = string(value)
+	return string(value), nil
+}
+
+// remember records a value derived from secrets, like an access token or a
+// rendered field that used one, so it is redacted as well.
+func (r *secretResolver) remember(name, value string) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.values[name] = value
+}
+
+func (r *secretResolver) redact(s string) string {
+	if r == nil {
+		return s
+	}
+	r.mu.Lock()
+	values := make([]string, 0, len(r.values))
+	for _, value := range r.values {
+		values = append(values, value)
+	}
+	r.mu.Unlock()
+	// Longer values go first so a rendered field is replaced whole rather
+	// than around the secret inside it.
+	sort.Slice(values, func(i, j int) bool { return len(values[i]) > len(values[j]) })
+
+	for _, value := range values {
+		if value == "" {
+			continue
+		}
+		// Values also show up escaped in URLs and in stored JSON bodies.
+		variants := []string{value, url.QueryEscape(value), url.PathEscape(value)}
+		if escaped, _ := json.Marshal(value); len(escaped) > 2 {
+			variants = append(variants, string(escaped[1:len(escaped)-1]))
+		}
+		for _, variant := range variants {
+			s = strings.ReplaceAll(s, variant, redacted)
+		}
+	}
+	return s
+}
+
+// redactedError keeps the wrapped error for classification but reports a
+// scrubbed message.
+type redactedError struct {
+	err error
+	msg string
+}
+
+func (e redactedError) Error() string {
+	return e.msg
+}
+
+func (e redactedError) Unwrap() error {
+	return e.err
+}
+
+// redactResult scrubs resolved secret values from what is stored for an
+// attempt. The raw body is left alone because assertions run against it.
+func (r *secretResolver) redactResult(target entity.TargetData, result Result) (entity.TargetData, Result) {
+	if r == nil {
+		return target, result
+	}
+	r.mu.Lock()
+	resolved := len(r.values)
+	r.mu.Unlock()
+	if resolved == 0 {
+		return target, result
+	}
+
+	target.URL = r.redact(target.URL)
+
+	if result.Header != nil {
+		header := make(http.Header, len(result.Header))
+		for k, values := range result.Header {
+			for _, v := range values {
+				header.Add(k, r.redact(v))
+			}
+		}
+		result.Header = header
+	}
+	if result.Body.stored != nil {
+		result.Body.stored = []byte(r.redact(string(result.Body.stored)))
+	}
+	if result.Err != nil {
+		if msg := r.redact(result.Err.Error()); msg != result.Err.Error() {
+			result.Err = redactedError{err: result.Err, msg: msg}
+		}
+	}
+	result.Stderr = r.redact(result.Stderr)
+
+	request := result.Request
+	request.URL = r.redact(request.URL)
+	if request.Headers != nil {
+		headers := make(map[string]string, len(request.Headers))
+		for k, v := range request.Headers {
+			headers[k] = r.redact(v)
+		}
+		request.Headers = headers
+	}
+	if request.Body != nil {
+		request.Body = json.RawMessage(r.redact(string(request.Body)))
+	}
+	result.Request = request
+
+	return target, result
+}
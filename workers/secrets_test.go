@@ -0,0 +1,102 @@
+package workers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	entity "scheduler/application/entity"
+	"scheduler/clock"
+	"scheduler/database"
+	"scheduler/secrets"
+	"strings"
+	"testing"
+)
+
+func testSecrets(t *testing.T, values map[string]string) *secretResolver {
+	t.Helper()
+	box, err := secrets.NewBox(make([]byte, 32))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &secretResolver{ctx: context.Background(), box: box, values: values}
+}
+
+func TestRedactDerivedFields(t *testing.T) {
+	resolver := testSecrets(t, map[string]string{"pw": "hunter2"})
+	vars := runVars(database.Task{}, database.TaskRun{}, nil, resolver, clock.New())
+
+	target := entity.TargetData{
+		Method: http.MethodPost,
+		URL:    "https://api.example.com/items",
+		Headers: map[string]string{
+			"Authorization": `Basic {{printf "u:%s" (secret "pw") | b64enc}}`,
+			"X-Run":         "{{.Run}}",
+		},
+		Payload: map[string]interface{}{
+			"token": `{{secret "pw" | upper}}`,
+			"name":  "report",
+		},
+	}
+	rendered, err := renderTarget(target, vars)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := rendered.Headers["Authorization"]; got != "Basic dTpodW50ZXIy" {
+		t.Fatalf("rendered Authorization = %q", got)
+	}
+
+	// The server echoes the credential back in a header and the body.
+	result := Result{
+		Header:  http.Header{"X-Echo": {"Basic dTpodW50ZXIy"}},
+		Body:    capturedBody{stored: []byte(`{"seen":"HUNTER2"}`)},
+		Request: requestSnapshot(rendered),
+	}
+	stored, result := resolver.redactResult(rendered, result)
+
+	if got := result.Request.Headers["Authorization"]; got != redacted {
+		t.Errorf("snapshot Authorization = %q, want %q", got, redacted)
+	}
+	if got := result.Request.Headers["X-Run"]; got != "0" {
+		t.Errorf("snapshot X-Run = %q, fields without secrets must be kept", got)
+	}
+	var body map[string]string
+	if err := json.Unmarshal(result.Request.Body, &body); err != nil {
+		t.Fatalf("snapshot body %s: %v", result.Request.Body, err)
+	}
+	if body["token"] != redacted || body["name"] != "report" {
+		t.Errorf("snapshot body = %v", body)
+	}
+	if got := result.Header.Get("X-Echo"); got != redacted {
+		t.Errorf("response header = %q", got)
+	}
+	if got := string(result.Body.stored); got != `{"seen":"[REDACTED]"}` {
+		t.Errorf("response body = %s", got)
+	}
+	if stored.URL != target.URL {
+		t.Errorf("URL = %q", stored.URL)
+	}
+}
+
+func TestRedactTemplateErrors(t *testing.T) {
+	resolver := testSecrets(t, map[string]string{"ttl": "s3cret"})
+	vars := runVars(database.Task{}, database.TaskRun{}, nil, resolver, clock.New())
+
+	target := entity.TargetData{Method: http.MethodGet, URL: `https://api.example.com/?until={{add (secret "ttl") now | unix}}`}
+	_, err := renderTarget(target, vars)
+	if err == nil || !strings.Contains(err.Error(), "s3cret") {
+		t.Fatalf("render error = %v, expected it to quote the value", err)
+	}
+
+	_, result := resolver.redactResult(target, Result{Err: templateError(err), Request: requestSnapshot(target)})
+	if strings.Contains(result.Err.Error(), "s3cret") {
+		t.Errorf("error still holds the secret: %v", result.Err)
+	}
+	if errorClass(result.Err) != errorClassRequest {
+		t.Errorf("error class = %s, redaction must keep it", errorClass(result.Err))
+	}
+	var requestErr requestError
+	if !errors.As(result.Err, &requestErr) {
+		t.Error("redacted error no longer wraps the original")
+	}
+}
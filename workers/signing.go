@@ -0,0 +1,81 @@
+package workers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	entity "scheduler/application/entity"
+	"scheduler/database"
+	"scheduler/signature"
+	"strconv"
+	"strings"
+	"time"
+)
+
+func taskSigning(task database.Task) *entity.SigningConfig {
+	if task.Signing == nil || string(task.Signing) == "null" {
+		return nil
+	}
+	var cfg entity.SigningConfig
+	if err := json.Unmarshal(task.Signing, &cfg); err != nil {
+		return nil
+	}
+	return &cfg
+}
+
+// ValidateSigning checks a task's signing config against its action type.
+func ValidateSigning(actionType string, cfg entity.SigningConfig) error {
+	if actionType != "" && actionType != DefaultActionType {
+		return fmt.Errorf("signing is only supported for %s actions", DefaultActionType)
+	}
+	if cfg.Secret == "" {
+		return errors.New("secret is required")
+	}
+	for _, name := range []string{cfg.SignatureHeader, cfg.TimestampHeader} {
+		if strings.ContainsAny(name, " \t:\r\n") {
+			return fmt.Errorf("invalid header name %q", name)
+		}
+	}
+	return nil
+}
+
+// signTarget adds the timestamp and signature headers for the request that
+// will be built from target.
+func signTarget(target entity.TargetData, cfg entity.SigningConfig, key []byte, now time.Time) (entity.TargetData, error) {
+	body, err := targetBody(target)
+	if err != nil {
+		return target, err
+	}
+
+	sigHeader := cfg.SignatureHeader
+	if sigHeader == "" {
+		sigHeader = signature.DefaultSignatureHeader
+	}
+	tsHeader := cfg.TimestampHeader
+	if tsHeader == "" {
+		tsHeader = signature.DefaultTimestampHeader
+	}
+
+	timestamp := now.Unix()
+	headers := make(map[string]string, len(target.Headers)+2)
+	for k, v := range target.Headers {
+		headers[k] = v
+	}
+	headers[tsHeader] = strconv.FormatInt(timestamp, 10)
+	headers[sigHeader] = signature.Sign(key, timestamp, target.Method, target.URL, body)
+	target.Headers = headers
+
+	return target, nil
+}
+
+func (e HTTPExecutor) sign(target entity.TargetData, cfg entity.SigningConfig, secrets *secretResolver) (entity.TargetData, error) {
+	key, err := secrets.resolve(cfg.Secret)
+	if err != nil {
+		return target, requestError{fmt.Errorf("signing: %w", err)}
+	}
+	signed, err := signTarget(target, cfg, []byte(key), e.clock.Now())
+	if err != nil {
+		return target, requestError{fmt.Errorf("signing: %w", err)}
+	}
+	return signed, nil
+}
@@ -0,0 +1,210 @@
+package workers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	entity "scheduler/application/entity"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const (
+	defaultSQLMaxRows = 100
+	maxSQLRows        = 1000
+)
+
+// SQLExecutor runs a statement for "sql" actions against one of the
+// connections it was created with.
+type SQLExecutor struct {
+	conns map[string]*pgxpool.Pool
+}
+
+func NewSQLExecutor(conns map[string]*pgxpool.Pool) *SQLExecutor {
+	return &SQLExecutor{conns: conns}
+}
+
+type sqlOutput struct {
+	RowsAffected int64           `json:"rows_affected"`
+	Columns      []string        `json:"columns,omitempty"`
+	Rows         [][]interface{} `json:"rows,omitempty"`
+	Truncated    bool            `json:"truncated,omitempty"`
+}
+
+func sqlConfig(raw json.RawMessage) (entity.SQLConfig, error) {
+	var cfg entity.SQLConfig
+	if len(raw) == 0 {
+		return cfg, errors.New("config is required for sql actions")
+	}
+	if err := json.Unmarshal(raw, &cfg); err != nil {
+		return cfg, fmt.Errorf("invalid sql config: %w", err)
+	}
+	return cfg, nil
+}
+
+func (e *SQLExecutor) ValidateConfig(action entity.ActionData) error {
+	cfg, err := sqlConfig(action.Config)
+	if err != nil {
+		return err
+	}
+	return e.check(cfg)
+}
+
+func (e *SQLExecutor) check(cfg entity.SQLConfig) error {
+	if _, ok := e.conns[cfg.Connection]; !ok {
+		names := make([]string, 0, len(e.conns))
+		for name := range e.conns {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return fmt.Errorf("unknown connection %q (configured: %v)", cfg.Connection, names)
+	}
+	if strings.TrimSpace(cfg.Statement) == "" {
+		return errors.New("statement is required for sql actions")
+	}
+	if cfg.MaxRows < 0 || cfg.MaxRows > maxSQLRows {
+		return fmt.Errorf("max_rows must be between 0 and %d", maxSQLRows)
+	}
+	if cfg.TimeoutMs < 0 {
+		return errors.New("timeout_ms must not be negative")
+	}
+	return nil
+}
+
+func (e *SQLExecutor) Execute(ctx context.Context, job Execution) Result {
+	result := Result{Request: entity.DeadLetterRequest{Config: job.Config}}
+
+	cfg, err := sqlConfig(job.Config)
+	if err == nil {
+		err = e.check(cfg)
+	}
+	if err != nil {
+		result.Err = requestError{err}
+		return result
+	}
+
+	timeout := time.Duration(cfg.TimeoutMs) * time.Millisecond
+	if timeout == 0 {
+		timeout = time.Duration(job.Timeouts.TotalMs) * time.Millisecond
+	}
+	maxRows := cfg.MaxRows
+	if maxRows == 0 {
+		maxRows = defaultSQLMaxRows
+	}
+
+	start := time.Now()
+	out, err := runStatement(ctx, e.conns[cfg.Connection], cfg, timeout, maxRows)
+	result.Duration = time.Since(start)
+	if err != nil {
+		result.Err = sqlError(err)
+		return result
+	}
+
+	body, err := json.Marshal(out)
+	if err != nil {
+		result.Err = fmt.Errorf("encoding result set: %w", err)
+		return result
+	}
+	result.Body, _ = captureBody(bytes.NewReader(body), "application/json", job.Capture, job.NeedBody)
+	result.Success = true
+
+	return result
+}
+
+func runStatement(ctx context.Context, pool *pgxpool.Pool, cfg entity.SQLConfig, timeout time.Duration, maxRows int) (sqlOutput, error) {
+	var out sqlOutput
+
+	conn, err := pool.Acquire(ctx)
+	if err != nil {
+		return out, err
+	}
+	defer conn.Release()
+
+	if timeout > 0 {
+		ms := strconv.FormatInt(timeout.Milliseconds(), 10)
+		if _, err := conn.Exec(ctx, "SELECT set_config('statement_timeout', $1, false)", ms); err != nil {
+			return out, err
+		}
+		defer func() {
+			// A connection still carrying the timeout must not go back into
+			// the pool, so it is closed if the reset fails.
+			if _, err := conn.Exec(context.WithoutCancel(ctx), "RESET statement_timeout"); err != nil {
+				conn.Conn().Close(context.WithoutCancel(ctx))
+			}
+		}()
+	}
+
+	// Without arguments the simple protocol is used so that utility
+	// statements like VACUUM run exactly as written.
+	args := cfg.Args
+	if len(args) == 0 {
+		args = []interface{}{pgx.QueryExecModeSimpleProtocol}
+	}
+
+	rows, err := conn.Query(ctx, cfg.Statement, args...)
+	if err != nil {
+		return out, err
+	}
+	defer rows.Close()
+
+	for _, field := range rows.FieldDescriptions() {
+		out.Columns = append(out.Columns, field.Name)
+	}
+	for rows.Next() {
+		if len(out.Rows) == maxRows {
+			out.Truncated = true
+			break
+		}
+		values, err := rows.Values()
+		if err != nil {
+			return out, err
+		}
+		for i, v := range values {
+			values[i] = sqlValue(v)
+		}
+		out.Rows = append(out.Rows, values)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return out, err
+	}
+	out.RowsAffected = rows.CommandTag().RowsAffected()
+
+	return out, nil
+}
+
+// sqlValue makes values that don't encode usefully to JSON readable.
+func sqlValue(v interface{}) interface{} {
+	if uuid, ok := v.([16]byte); ok {
+		return uuidString(uuid)
+	}
+	return v
+}
+
+// sqlError marks errors that will fail the same way on every attempt, like
+// syntax errors or missing permissions, so they aren't retried.
+// Serialization failures, resource exhaustion and cancellations abort the
+// statement and are retried under the task's retry policy. Connection
+// problems leave it unknown whether the statement committed and are only
+// retried when the policy sets retry_on_network_error.
+func sqlError(err error) error {
+	var pgErr *pgconn.PgError
+	if !errors.As(err, &pgErr) {
+		return err
+	}
+	switch pgErr.Code[:2] {
+	case "08":
+		return err
+	case "40", "53", "57":
+		return abortedError{err}
+	}
+	return requestError{err}
+}
@@ -0,0 +1,74 @@
+package workers
+
+import (
+	"errors"
+	"io"
+	"scheduler/database"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+func TestSQLRetries(t *testing.T) {
+	pgError := func(code string) error {
+		return sqlError(&pgconn.PgError{Code: code, Message: "failed"})
+	}
+
+	tests := []struct {
+		name  string
+		err   error
+		class string
+		// retried under no policy, {"max_attempts":3} and
+		// {"max_attempts":3,"retry_on_network_error":true}.
+		retried [3]bool
+	}{
+		{"syntax error", pgError("42601"), errorClassRequest, [3]bool{false, false, false}},
+		{"permission denied", pgError("42501"), errorClassRequest, [3]bool{false, false, false}},
+		{"unique violation", pgError("23505"), errorClassRequest, [3]bool{false, false, false}},
+		{"serialization failure", pgError("40001"), errorClassNetwork, [3]bool{false, true, true}},
+		{"deadlock", pgError("40P01"), errorClassNetwork, [3]bool{false, true, true}},
+		{"too many connections", pgError("53300"), errorClassNetwork, [3]bool{false, true, true}},
+		{"statement timeout", pgError("57014"), errorClassTimeout, [3]bool{false, true, true}},
+		{"admin shutdown", pgError("57P01"), errorClassNetwork, [3]bool{false, true, true}},
+		{"connection failure", pgError("08006"), errorClassNetwork, [3]bool{false, false, true}},
+		{"connection lost", sqlError(io.ErrUnexpectedEOF), errorClassNetwork, [3]bool{false, false, true}},
+	}
+
+	policies := []string{"", `{"max_attempts":3}`, `{"max_attempts":3,"retry_on_network_error":true}`}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if class := errorClass(tt.err); class != tt.class {
+				t.Errorf("errorClass = %s, want %s", class, tt.class)
+			}
+			var pgErr *pgconn.PgError
+			if !errors.Is(tt.err, io.ErrUnexpectedEOF) && !errors.As(tt.err, &pgErr) {
+				t.Errorf("%v lost the underlying error", tt.err)
+			}
+
+			for i, raw := range policies {
+				task := database.Task{ActionType: "sql"}
+				if raw != "" {
+					task.RetryPolicy = []byte(raw)
+				}
+				policy := taskRetryPolicy(task)
+				if got := shouldRetry(policy, 1, Result{Err: tt.err}); got != tt.retried[i] {
+					t.Errorf("policy %q: shouldRetry = %v, want %v", raw, got, tt.retried[i])
+				}
+			}
+		})
+	}
+}
+
+func TestRetryOnNetworkErrorDefault(t *testing.T) {
+	for actionType, want := range map[string]bool{"": true, "http": true, "grpc": true, "sql": false} {
+		policy := taskRetryPolicy(database.Task{ActionType: actionType, RetryPolicy: []byte(`{"max_attempts":2}`)})
+		if *policy.RetryOnNetworkError != want {
+			t.Errorf("%q: retry_on_network_error = %v, want %v", actionType, *policy.RetryOnNetworkError, want)
+		}
+	}
+
+	policy := taskRetryPolicy(database.Task{ActionType: "http", RetryPolicy: []byte(`{"max_attempts":2,"retry_on_network_error":false}`)})
+	if *policy.RetryOnNetworkError {
+		t.Error("an explicit retry_on_network_error was overridden")
+	}
+}
@@ -0,0 +1,307 @@
+package workers
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	entity "scheduler/application/entity"
+	"scheduler/clock"
+	"scheduler/database"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// RunVars are the variables available to templates in a task's URL, headers
+// and payload. They are rendered again for every attempt.
+type RunVars struct {
+	TaskID      string
+	TaskName    string
+	RunID       string
+	Run         int
+	Attempt     int
+	Target      int
+	ScheduledAt time.Time
+	FiredAt     time.Time
+	// IdempotencyKey is the same for every attempt and every run of one
+	// scheduled occurrence.
+	IdempotencyKey string
+	// Prev is the last result recorded before this run, nil on the first.
+	Prev *PrevResult
+
+	secrets *secretResolver
+	now     func() time.Time
+}
+
+type PrevResult struct {
+	StatusCode int
+	Success    bool
+	Error      string
+	RunAt      time.Time
+	Headers    map[string]string
+	Body       string
+	// JSON is the decoded body when it was JSON.
+	JSON interface{}
+}
+
+// templateFuncs are the helpers available to action templates. None of them
+// touch the filesystem, environment or network. "now" is added per template
+// from the worker's clock.
+var templateFuncs = template.FuncMap{
+	"date": func(layout string, t time.Time) string { return t.Format(layout) },
+	"unix": func(t time.Time) int64 { return t.Unix() },
+	"add": func(d string, t time.Time) (time.Time, error) {
+		parsed, err := time.ParseDuration(d)
+		return t.Add(parsed), err
+	},
+	"uuid": func() string {
+		var b [16]byte
+		rand.Read(b[:])
+		b[6] = b[6]&0x0f | 0x40
+		b[8] = b[8]&0x3f | 0x80
+		return uuidString(b)
+	},
+	"b64enc": func(s string) string { return base64.StdEncoding.EncodeToString([]byte(s)) },
+	"b64dec": func(s string) (string, error) {
+		b, err := base64.StdEncoding.DecodeString(s)
+		return string(b), err
+	},
+	"json": func(v interface{}) (string, error) {
+		b, err := json.Marshal(v)
+		return string(b), err
+	},
+	"upper": strings.ToUpper,
+	"lower": strings.ToLower,
+	"trim":  strings.TrimSpace,
+	"default": func(def, v interface{}) interface{} {
+		if v == nil || v == "" {
+			return def
+		}
+		return v
+	},
+}
+
+func isTemplate(s string) bool {
+	return strings.Contains(s, "{{")
+}
+
+// parseTemplate parses an action template with templateFuncs and the per-run
+// funcs from RunVars.funcs.
+func parseTemplate(name, text string, funcs template.FuncMap) (*template.Template, error) {
+	return template.New(name).Option("missingkey=error").Funcs(templateFuncs).Funcs(funcs).Parse(text)
+}
+
+// funcs returns the "secret" and "now" helpers for one render, and a done
+// func to call with the output. Whatever a template builds from a secret,
+// like an encoded credential, is redacted as a whole.
+func (vars RunVars) funcs() (template.FuncMap, func(out string)) {
+	usedSecret := false
+	funcs := template.FuncMap{
+		"secret": func(name string) (string, error) {
+			usedSecret = true
+			return vars.secrets.resolve(name)
+		},
+		"now": vars.now,
+	}
+	done := func(out string) {
+		if usedSecret {
+			vars.secrets.remember("rendered:"+out, out)
+		}
+	}
+	return funcs, done
+}
+
+func renderString(name, text string, vars RunVars) (string, error) {
+	if !isTemplate(text) {
+		return text, nil
+	}
+	funcs, done := vars.funcs()
+	tmpl, err := parseTemplate(name, text, funcs)
+	if err != nil {
+		return "", err
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, vars); err != nil {
+		return "", err
+	}
+	done(buf.String())
+	return buf.String(), nil
+}
+
+// renderTarget returns target with the templates in its URL, header values
+// and payload strings rendered. The stored target is left untouched.
+func renderTarget(target entity.TargetData, vars RunVars) (entity.TargetData, error) {
+	rendered := target
+
+	url, err := renderString("url", target.URL, vars)
+	if err != nil {
+		return target, err
+	}
+	rendered.URL = url
+
+	if len(target.Headers) > 0 {
+		rendered.Headers = make(map[string]string, len(target.Headers))
+		for k, v := range target.Headers {
+			value, err := renderString("header "+k, v, vars)
+			if err != nil {
+				return target, err
+			}
+			rendered.Headers[k] = value
+		}
+	}
+
+	if target.Payload != nil {
+		raw, err := json.Marshal(target.Payload)
+		if err != nil {
+			return target, err
+		}
+		if isTemplate(string(raw)) {
+			var payload interface{}
+			if err := json.Unmarshal(raw, &payload); err != nil {
+				return target, err
+			}
+			if payload, err = renderValue(payload, vars); err != nil {
+				return target, err
+			}
+			rendered.Payload = payload
+		}
+	}
+
+	return rendered, nil
+}
+
+// renderValue renders every string in a decoded JSON value, keeping the
+// document's structure, so templates can't produce invalid JSON.
+func renderValue(v interface{}, vars RunVars) (interface{}, error) {
+	switch v := v.(type) {
+	case string:
+		return renderString("payload", v, vars)
+	case []interface{}:
+		for i := range v {
+			rendered, err := renderValue(v[i], vars)
+			if err != nil {
+				return nil, err
+			}
+			v[i] = rendered
+		}
+	case map[string]interface{}:
+		for k := range v {
+			rendered, err := renderValue(v[k], vars)
+			if err != nil {
+				return nil, err
+			}
+			v[k] = rendered
+		}
+	}
+	return v, nil
+}
+
+// checkFuncs stand in for the per-run funcs when templates are only parsed.
+var checkFuncs = template.FuncMap{"secret": (*secretResolver)(nil).resolve, "now": clock.New().Now}
+
+// CheckTemplates parses every template in an action so syntax errors are
+// reported when the task is saved rather than when it runs.
+func CheckTemplates(action entity.ActionData) error {
+	targets := []entity.TargetData{{URL: action.URL, Headers: action.Headers, Payload: action.Payload}}
+	targets = append(targets, action.Targets...)
+
+	var errs []error
+	check := func(name, text string) {
+		if !isTemplate(text) {
+			return
+		}
+		if _, err := parseTemplate(name, text, checkFuncs); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	var walk func(v interface{})
+	walk = func(v interface{}) {
+		switch v := v.(type) {
+		case string:
+			check("payload", v)
+		case []interface{}:
+			for _, item := range v {
+				walk(item)
+			}
+		case map[string]interface{}:
+			for _, item := range v {
+				walk(item)
+			}
+		}
+	}
+
+	for _, target := range targets {
+		check("url", target.URL)
+		for k, v := range target.Headers {
+			check("header "+k, v)
+		}
+		if target.Payload != nil {
+			var payload interface{}
+			raw, _ := json.Marshal(target.Payload)
+			json.Unmarshal(raw, &payload)
+			walk(payload)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func prevResult(result database.TaskResult) *PrevResult {
+	prev := &PrevResult{
+		StatusCode: int(result.StatusCode),
+		Success:    result.Success,
+		Error:      result.ErrorMessage.String,
+		RunAt:      result.RunAt.Time,
+		Headers:    map[string]string{},
+	}
+
+	var headers map[string][]string
+	json.Unmarshal(result.ResponseHeaders, &headers)
+	for k, v := range headers {
+		if len(v) > 0 {
+			prev.Headers[k] = v[0]
+		}
+	}
+
+	// Bodies are stored as JSON documents, text as a JSON string and
+	// anything else base64 encoded.
+	switch result.BodyEncoding.String {
+	case bodyEncodingJSON:
+		json.Unmarshal(result.ResponseBody, &prev.JSON)
+		prev.Body = string(result.ResponseBody)
+	case bodyEncodingText:
+		json.Unmarshal(result.ResponseBody, &prev.Body)
+	case bodyEncodingBase64:
+		var encoded string
+		json.Unmarshal(result.ResponseBody, &encoded)
+		decoded, _ := base64.StdEncoding.DecodeString(encoded)
+		prev.Body = string(decoded)
+	}
+	return prev
+}
+
+func runVars(task database.Task, run database.TaskRun, prev *PrevResult, secrets *secretResolver, clk clock.Clock) RunVars {
+	vars := RunVars{
+		TaskID:   uuidString(task.ID.Bytes),
+		TaskName: task.Name,
+		RunID:    uuidString(run.ID.Bytes),
+		Run:      int(run.RunNumber),
+		FiredAt:  run.StartedAt.Time,
+		Prev:     prev,
+		secrets:  secrets,
+		now:      clk.Now,
+	}
+	vars.ScheduledAt = vars.FiredAt
+	vars.IdempotencyKey = vars.RunID
+	if run.ScheduledAt.Valid {
+		vars.ScheduledAt = run.ScheduledAt.Time
+		vars.IdempotencyKey = occurrenceKey(task.ID.Bytes, run.ScheduledAt.Time)
+	}
+	return vars
+}
+
+func templateError(err error) error {
+	return requestError{fmt.Errorf("rendering templates: %w", err)}
+}
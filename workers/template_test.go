@@ -0,0 +1,21 @@
+package workers
+
+import (
+	"scheduler/clock"
+	"scheduler/database"
+	"testing"
+	"time"
+)
+
+func TestRenderNowUsesClock(t *testing.T) {
+	clk := clock.NewFake(time.Date(2026, 3, 8, 6, 59, 0, 0, time.UTC))
+	vars := runVars(database.Task{}, database.TaskRun{}, nil, nil, clk)
+
+	got, err := renderString("url", `https://example.com/?at={{now | unix}}&in={{add "1m" now | date "15:04"}}`, vars)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "https://example.com/?at=1772953140&in=07:00"; got != want {
+		t.Errorf("rendered %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,86 @@
+package workers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net"
+	"os/exec"
+	entity "scheduler/application/entity"
+	"scheduler/database"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+const (
+	errorClassTimeout   = "timeout"
+	errorClassCanceled  = "canceled"
+	errorClassNetwork   = "network"
+	errorClassRequest   = "request"
+	errorClassStatus    = "status"
+	errorClassAssertion = "assertion"
+	errorClassExit      = "exit"
+	errorClassCircuit   = "circuit_open"
+)
+
+type requestError struct {
+	err error
+}
+
+func (e requestError) Error() string {
+	return e.err.Error()
+}
+
+func (e requestError) Unwrap() error {
+	return e.err
+}
+
+func errorClass(err error) string {
+	if err == nil {
+		return ""
+	}
+
+	var circuitErr circuitOpenError
+	if errors.As(err, &circuitErr) {
+		return errorClassCircuit
+	}
+
+	var reqErr requestError
+	if errors.As(err, &reqErr) {
+		return errorClassRequest
+	}
+
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return errorClassExit
+	}
+
+	var netErr net.Error
+	if errors.Is(err, context.DeadlineExceeded) || (errors.As(err, &netErr) && netErr.Timeout()) {
+		return errorClassTimeout
+	}
+	// 57014 is query_canceled, which is what a statement timeout raises.
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "57014" {
+		return errorClassTimeout
+	}
+	if errors.Is(err, context.Canceled) {
+		return errorClassCanceled
+	}
+
+	return errorClassNetwork
+}
+
+func taskTimeouts(task database.Task, cfg Config) entity.TimeoutConfig {
+	var timeouts entity.TimeoutConfig
+	if task.Timeouts != nil {
+		json.Unmarshal(task.Timeouts, &timeouts)
+	}
+
+	if timeouts.TotalMs == 0 {
+		timeouts.TotalMs = int(cfg.DefaultTimeout / time.Millisecond)
+	}
+
+	return timeouts
+}
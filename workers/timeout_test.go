@@ -0,0 +1,44 @@
+package workers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"scheduler/database"
+	"testing"
+	"time"
+)
+
+func TestTaskTimeouts(t *testing.T) {
+	cfg := Config{DefaultTimeout: 30 * time.Second}
+
+	timeouts := taskTimeouts(database.Task{}, cfg)
+	if timeouts.TotalMs != 30000 || timeouts.ConnectMs != 0 || timeouts.RunMs != 0 {
+		t.Errorf("defaults = %+v, want only total_ms from the server", timeouts)
+	}
+
+	timeouts = taskTimeouts(database.Task{Timeouts: []byte(`{"connect_ms":500,"total_ms":2000,"run_ms":10000}`)}, cfg)
+	if timeouts.ConnectMs != 500 || timeouts.TotalMs != 2000 || timeouts.RunMs != 10000 {
+		t.Errorf("task timeouts = %+v", timeouts)
+	}
+}
+
+func TestErrorClass(t *testing.T) {
+	tests := []struct {
+		err  error
+		want string
+	}{
+		{nil, ""},
+		{context.DeadlineExceeded, errorClassTimeout},
+		{fmt.Errorf("attempt: %w", context.DeadlineExceeded), errorClassTimeout},
+		{context.Canceled, errorClassCanceled},
+		{requestError{errors.New("bad url")}, errorClassRequest},
+		{io.ErrUnexpectedEOF, errorClassNetwork},
+	}
+	for _, tt := range tests {
+		if got := errorClass(tt.err); got != tt.want {
+			t.Errorf("errorClass(%v) = %q, want %q", tt.err, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,177 @@
+package workers
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	entity "scheduler/application/entity"
+	"scheduler/database"
+
+	"github.com/jackc/pgx/v5"
+)
+
+var tlsVersions = map[string]uint16{
+	"":    tls.VersionTLS12,
+	"1.2": tls.VersionTLS12,
+	"1.3": tls.VersionTLS13,
+}
+
+func taskTLS(task database.Task) *entity.TLSConfig {
+	if task.Tls == nil || string(task.Tls) == "null" {
+		return nil
+	}
+	var cfg entity.TLSConfig
+	if err := json.Unmarshal(task.Tls, &cfg); err != nil {
+		return nil
+	}
+	return &cfg
+}
+
+// ValidateTLS checks a TLS config's certificates and settings. The profile
+// reference and the client key secret are checked by the caller since they
+// live in the database.
+func ValidateTLS(actionType string, cfg entity.TLSConfig) error {
+	if actionType != "" && actionType != DefaultActionType {
+		return fmt.Errorf("tls is only supported for %s actions", DefaultActionType)
+	}
+	if _, ok := tlsVersions[cfg.MinVersion]; !ok {
+		return fmt.Errorf("min_version must be 1.2 or 1.3, got %q", cfg.MinVersion)
+	}
+	if cfg.CACert != "" {
+		if err := checkCertificates("ca_cert", cfg.CACert); err != nil {
+			return err
+		}
+	}
+	if cfg.ClientCert != "" {
+		// A private key pasted here would be stored in plain text.
+		if err := checkCertificates("client_cert", cfg.ClientCert); err != nil {
+			return err
+		}
+	}
+	if cfg.Profile == "" && (cfg.ClientCert == "") != (cfg.ClientKeyRef == "") {
+		return errors.New("client_cert and client_key_ref must be set together")
+	}
+	return nil
+}
+
+// checkCertificates makes sure field holds at least one parseable PEM
+// certificate and nothing else.
+func checkCertificates(field, data string) error {
+	rest := []byte(data)
+	count := 0
+	for {
+		var block *pem.Block
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			break
+		}
+		if block.Type != "CERTIFICATE" {
+			return fmt.Errorf("%s contains a %s block, only certificates are allowed", field, block.Type)
+		}
+		if _, err := x509.ParseCertificate(block.Bytes); err != nil {
+			return fmt.Errorf("%s: %w", field, err)
+		}
+		count++
+	}
+	if count == 0 {
+		return fmt.Errorf("%s contains no PEM certificates", field)
+	}
+	return nil
+}
+
+// mergeTLS fills the empty fields of cfg from a stored profile.
+func mergeTLS(profile, cfg entity.TLSConfig) entity.TLSConfig {
+	if cfg.CACert == "" {
+		cfg.CACert = profile.CACert
+	}
+	if cfg.ClientCert == "" && cfg.ClientKeyRef == "" {
+		cfg.ClientCert = profile.ClientCert
+		cfg.ClientKeyRef = profile.ClientKeyRef
+	}
+	if cfg.ServerName == "" {
+		cfg.ServerName = profile.ServerName
+	}
+	if cfg.MinVersion == "" {
+		cfg.MinVersion = profile.MinVersion
+	}
+	cfg.InsecureSkipVerify = cfg.InsecureSkipVerify || profile.InsecureSkipVerify
+	return cfg
+}
+
+// tlsConfig resolves a task's TLS settings, including its profile and client
+// key, into the config for the HTTP transport.
+func (e HTTPExecutor) tlsConfig(ctx context.Context, cfg entity.TLSConfig, secrets *secretResolver) (*tls.Config, error) {
+	if cfg.Profile != "" {
+		stored, err := e.db.GetTLSProfile(ctx, cfg.Profile)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, requestError{fmt.Errorf("tls profile %q not found", cfg.Profile)}
+		}
+		if err != nil {
+			return nil, fmt.Errorf("loading tls profile %q: %w", cfg.Profile, err)
+		}
+		var profile entity.TLSConfig
+		if err := json.Unmarshal(stored.Config, &profile); err != nil {
+			return nil, requestError{fmt.Errorf("tls profile %q: %w", cfg.Profile, err)}
+		}
+		cfg = mergeTLS(profile, cfg)
+	}
+
+	var key string
+	if cfg.ClientCert != "" || cfg.ClientKeyRef != "" {
+		if cfg.ClientCert == "" || cfg.ClientKeyRef == "" {
+			return nil, requestError{errors.New("tls: client_cert and client_key_ref must be set together")}
+		}
+		var err error
+		if key, err = secrets.resolve(cfg.ClientKeyRef); err != nil {
+			return nil, requestError{fmt.Errorf("tls: %w", err)}
+		}
+	}
+
+	// Runs with the same settings share one config, and so one transport.
+	h := sha256.New()
+	json.NewEncoder(h).Encode(cfg)
+	h.Write([]byte(key))
+	return e.transports.tlsConfig(hex.EncodeToString(h.Sum(nil)), func() (*tls.Config, error) {
+		return buildTLSConfig(cfg, key)
+	})
+}
+
+func buildTLSConfig(cfg entity.TLSConfig, key string) (*tls.Config, error) {
+	minVersion, ok := tlsVersions[cfg.MinVersion]
+	if !ok {
+		return nil, requestError{fmt.Errorf("unsupported tls min_version %q", cfg.MinVersion)}
+	}
+	config := &tls.Config{
+		MinVersion:         minVersion,
+		ServerName:         cfg.ServerName,
+		InsecureSkipVerify: cfg.InsecureSkipVerify,
+	}
+
+	if cfg.CACert != "" {
+		// Custom CAs are trusted alongside the system roots.
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM([]byte(cfg.CACert)) {
+			return nil, requestError{errors.New("tls: ca_cert contains no PEM certificates")}
+		}
+		config.RootCAs = pool
+	}
+
+	if cfg.ClientCert != "" {
+		cert, err := tls.X509KeyPair([]byte(cfg.ClientCert), []byte(key))
+		if err != nil {
+			return nil, requestError{fmt.Errorf("tls: loading client certificate: %w", err)}
+		}
+		config.Certificates = []tls.Certificate{cert}
+	}
+
+	return config, nil
+}
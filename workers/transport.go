@@ -0,0 +1,187 @@
+package workers
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	entity "scheduler/application/entity"
+	"sync"
+	"time"
+)
+
+const (
+	// maxTransports bounds how many distinct transports are kept. Each
+	// combination of timeouts, TLS settings and proxy needs its own.
+	maxTransports = 64
+	// maxDrainBytes is how much of an unread body is discarded so the
+	// connection can go back to the idle pool.
+	maxDrainBytes = 256 << 10
+)
+
+// TransportConfig tunes the connections http actions share. Proxy applies
+// to tasks without their own; when empty, HTTP_PROXY, HTTPS_PROXY and
+// NO_PROXY are used.
+type TransportConfig struct {
+	MaxIdleConns        int
+	MaxIdleConnsPerHost int
+	MaxConnsPerHost     int
+	IdleConnTimeout     time.Duration
+	DisableHTTP2        bool
+	Proxy               string
+}
+
+func DefaultTransportConfig() TransportConfig {
+	return TransportConfig{
+		MaxIdleConns:        200,
+		MaxIdleConnsPerHost: 32,
+		IdleConnTimeout:     90 * time.Second,
+	}
+}
+
+// CheckProxy validates a proxy URL from a task or the worker config.
+func CheckProxy(proxy string) error {
+	u, err := url.Parse(proxy)
+	if err != nil || u.Host == "" {
+		return errors.New("proxy must be an absolute URL")
+	}
+	switch u.Scheme {
+	case "http", "https", "socks5":
+		return nil
+	}
+	return fmt.Errorf("unsupported proxy scheme %q", u.Scheme)
+}
+
+type transportKey struct {
+	connect time.Duration
+	read    time.Duration
+	tls     *tls.Config
+	proxy   string
+}
+
+// transportPool shares http.Transports between runs so connections are
+// reused. Transports are keyed by the settings that live on the transport
+// rather than the request; TLS configs are interned by content so runs of
+// the same task land on the same transport.
+type transportPool struct {
+	cfg TransportConfig
+
+	mu         sync.Mutex
+	transports map[transportKey]*http.Transport
+	tlsConfigs map[string]*tls.Config
+}
+
+func newTransportPool(cfg TransportConfig) *transportPool {
+	return &transportPool{
+		cfg:        cfg,
+		transports: make(map[transportKey]*http.Transport),
+		tlsConfigs: make(map[string]*tls.Config),
+	}
+}
+
+// tlsConfig returns the config interned under key, building it on first use.
+func (p *transportPool) tlsConfig(key string, build func() (*tls.Config, error)) (*tls.Config, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if config, ok := p.tlsConfigs[key]; ok {
+		return config, nil
+	}
+	config, err := build()
+	if err != nil {
+		return nil, err
+	}
+	if len(p.tlsConfigs) >= maxTransports {
+		p.tlsConfigs = make(map[string]*tls.Config)
+	}
+	p.tlsConfigs[key] = config
+	return config, nil
+}
+
+func (p *transportPool) client(timeouts entity.TimeoutConfig, tlsConfig *tls.Config, proxy string) (*http.Client, error) {
+	key := transportKey{
+		connect: time.Duration(timeouts.ConnectMs) * time.Millisecond,
+		read:    time.Duration(timeouts.ReadMs) * time.Millisecond,
+		tls:     tlsConfig,
+		proxy:   proxy,
+	}
+	if key.proxy == "" {
+		key.proxy = p.cfg.Proxy
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	transport, ok := p.transports[key]
+	if !ok {
+		var err error
+		if transport, err = p.newTransport(key); err != nil {
+			return nil, err
+		}
+		if len(p.transports) >= maxTransports {
+			p.closeIdleLocked()
+			p.transports = make(map[transportKey]*http.Transport)
+		}
+		p.transports[key] = transport
+	}
+	return &http.Client{Transport: transport}, nil
+}
+
+func (p *transportPool) newTransport(key transportKey) (*http.Transport, error) {
+	proxy := http.ProxyFromEnvironment
+	if key.proxy != "" {
+		u, err := url.Parse(key.proxy)
+		if err != nil {
+			return nil, requestError{fmt.Errorf("invalid proxy: %w", err)}
+		}
+		proxy = http.ProxyURL(u)
+	}
+
+	connect := key.connect
+	if connect == 0 {
+		connect = 30 * time.Second
+	}
+	dialer := &net.Dialer{
+		Timeout:   connect,
+		KeepAlive: 30 * time.Second,
+	}
+
+	transport := &http.Transport{
+		Proxy:                 proxy,
+		DialContext:           dialer.DialContext,
+		ForceAttemptHTTP2:     !p.cfg.DisableHTTP2,
+		MaxIdleConns:          p.cfg.MaxIdleConns,
+		MaxIdleConnsPerHost:   p.cfg.MaxIdleConnsPerHost,
+		MaxConnsPerHost:       p.cfg.MaxConnsPerHost,
+		IdleConnTimeout:       p.cfg.IdleConnTimeout,
+		TLSHandshakeTimeout:   10 * time.Second,
+		ExpectContinueTimeout: time.Second,
+		ResponseHeaderTimeout: key.read,
+		TLSClientConfig:       key.tls,
+	}
+	if p.cfg.DisableHTTP2 {
+		// A non-nil empty map keeps the transport on HTTP/1.1.
+		transport.TLSNextProto = map[string]func(string, *tls.Conn) http.RoundTripper{}
+	}
+	return transport, nil
+}
+
+func (p *transportPool) closeIdleLocked() {
+	for _, transport := range p.transports {
+		transport.CloseIdleConnections()
+	}
+}
+
+func (p *transportPool) closeIdle() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.closeIdleLocked()
+}
+
+// drainBody discards what's left of a response body, up to a limit, and
+// closes it so the connection can be reused.
+func drainBody(body io.ReadCloser) {
+	io.CopyN(io.Discard, body, maxDrainBytes)
+	body.Close()
+}
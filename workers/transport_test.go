@@ -0,0 +1,119 @@
+package workers
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	entity "scheduler/application/entity"
+	"testing"
+	"time"
+)
+
+func TestCheckProxy(t *testing.T) {
+	tests := []struct {
+		proxy   string
+		wantErr bool
+	}{
+		{"http://proxy.internal:3128", false},
+		{"https://proxy.internal", false},
+		{"socks5://127.0.0.1:1080", false},
+		{"ftp://proxy.internal", true},
+		{"proxy.internal:3128", true},
+		{"", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.proxy, func(t *testing.T) {
+			if err := CheckProxy(tt.proxy); (err != nil) != tt.wantErr {
+				t.Errorf("CheckProxy = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestTransportPoolSharesTransports(t *testing.T) {
+	pool := newTransportPool(DefaultTransportConfig())
+	defer pool.closeIdle()
+	timeouts := entity.TimeoutConfig{ConnectMs: 1000, ReadMs: 1000}
+
+	first, err := pool.client(timeouts, nil, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := pool.client(timeouts, nil, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first.Transport != second.Transport {
+		t.Error("same settings got different transports")
+	}
+
+	proxied, err := pool.client(timeouts, nil, "http://proxy.internal:3128")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if proxied.Transport == first.Transport {
+		t.Error("a proxied client shares the direct transport")
+	}
+}
+
+func benchmarkServer(b *testing.B) *httptest.Server {
+	b.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"ok":true}`))
+	}))
+	b.Cleanup(server.Close)
+	return server
+}
+
+func benchmarkGet(b *testing.B, client *http.Client, url string) {
+	resp, err := client.Get(url)
+	if err != nil {
+		b.Fatal(err)
+	}
+	io.Copy(io.Discard, resp.Body)
+	resp.Body.Close()
+}
+
+// BenchmarkSharedTransport sends requests the way runs do now: every run
+// asks the pool for a client and gets the same transport back.
+func BenchmarkSharedTransport(b *testing.B) {
+	server := benchmarkServer(b)
+	pool := newTransportPool(DefaultTransportConfig())
+	b.Cleanup(pool.closeIdle)
+	timeouts := entity.TimeoutConfig{ConnectMs: 1000, ReadMs: 1000}
+
+	b.ReportAllocs()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			client, err := pool.client(timeouts, nil, "")
+			if err != nil {
+				b.Fatal(err)
+			}
+			benchmarkGet(b, client, server.URL)
+		}
+	})
+}
+
+// BenchmarkPerRunTransport builds a transport for every run and closes it
+// afterwards, so every request dials a new connection.
+func BenchmarkPerRunTransport(b *testing.B) {
+	server := benchmarkServer(b)
+	pool := newTransportPool(DefaultTransportConfig())
+	timeouts := entity.TimeoutConfig{ConnectMs: 1000, ReadMs: 1000}
+
+	b.ReportAllocs()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			transport, err := pool.newTransport(transportKey{
+				connect: time.Duration(timeouts.ConnectMs) * time.Millisecond,
+				read:    time.Duration(timeouts.ReadMs) * time.Millisecond,
+			})
+			if err != nil {
+				b.Fatal(err)
+			}
+			benchmarkGet(b, &http.Client{Transport: transport}, server.URL)
+			transport.CloseIdleConnections()
+		}
+	})
+}
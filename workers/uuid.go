@@ -0,0 +1,8 @@
+package workers
+
+import "fmt"
+
+// uuidString formats b in the canonical 8-4-4-4-12 form.
+func uuidString(b [16]byte) string {
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+}
@@ -2,28 +2,277 @@ package workers
 
 import (
 	"context"
+	"fmt"
 	"log"
+	entity "scheduler/application/entity"
+	"scheduler/database"
+	"sort"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
 )
 
-func (wp *WorkerPool) worker(ctx context.Context, id int) {
+type workerState struct {
+	id   int
+	stop chan struct{}
+	// stopping is set once the worker has been asked to exit. A busy worker
+	// finishes its current task first.
+	stopping bool
+	busy     bool
+	taskID   pgtype.UUID
+	taskName string
+	since    time.Time
+}
+
+func (wp *WorkerPool) worker(ctx context.Context, state *workerState) {
 	defer wp.wg.Done()
+	defer wp.removeWorker(state.id)
 
-	log.Printf("Worker %d started", id)
+	log.Printf("Worker %d started", state.id)
 
 	for {
 		select {
 		case <-ctx.Done():
-			log.Printf("Worker %d stopped", id)
+			log.Printf("Worker %d stopped", state.id)
+			return
+
+		case <-state.stop:
+			log.Printf("Worker %d retired", state.id)
+			return
+
+		case <-wp.draining:
+			log.Printf("Worker %d stopped", state.id)
 			return
 
 		case task, ok := <-wp.taskChan:
 			if !ok {
-				log.Printf("Worker %d: task channel closed", id)
+				log.Printf("Worker %d: task channel closed", state.id)
 				return
 			}
 
-			log.Printf("Worker %d: processing task %s", id, task.Name)
+			// The select may pick a task even though draining is closed.
+			if wp.isDraining() {
+				wp.requeue(task)
+				continue
+			}
+
+			log.Printf("Worker %d: processing task %s", state.id, task.Name)
+			wp.setBusy(state, task)
 			wp.executeTask(ctx, task)
+			wp.setIdle(state)
+		}
+	}
+}
+
+func (wp *WorkerPool) setBusy(state *workerState, task database.Task) {
+	now := wp.clock.Now()
+	wp.mu.Lock()
+	defer wp.mu.Unlock()
+	state.busy = true
+	state.taskID = task.ID
+	state.taskName = task.Name
+	state.since = now
+	if task.NextRun.Valid {
+		if wait := now.Sub(task.NextRun.Time); wait > wp.maxWait {
+			wp.maxWait = wait
+		}
+	}
+}
+
+func (wp *WorkerPool) setIdle(state *workerState) {
+	now := wp.clock.Now()
+	wp.mu.Lock()
+	defer wp.mu.Unlock()
+	state.busy = false
+	state.taskID = pgtype.UUID{}
+	state.taskName = ""
+	state.since = now
+}
+
+func (wp *WorkerPool) removeWorker(id int) {
+	wp.mu.Lock()
+	defer wp.mu.Unlock()
+	delete(wp.workers, id)
+}
+
+func (wp *WorkerPool) activeLocked() int {
+	active := 0
+	for _, state := range wp.workers {
+		if !state.stopping {
+			active++
+		}
+	}
+	return active
+}
+
+// spawnLocked starts n workers. It does nothing before Start, which spawns
+// the minimum itself, or once the pool is draining.
+func (wp *WorkerPool) spawnLocked(n int) {
+	if wp.ctx == nil || wp.isDraining() {
+		return
+	}
+	now := wp.clock.Now()
+	for i := 0; i < n; i++ {
+		wp.nextID++
+		state := &workerState{id: wp.nextID, stop: make(chan struct{}), since: now}
+		wp.workers[state.id] = state
+		wp.wg.Add(1)
+		go wp.worker(wp.ctx, state)
+	}
+}
+
+// retireLocked stops up to n workers, longest idle first. Busy workers are
+// only picked when busyToo is set, and exit after their current task.
+func (wp *WorkerPool) retireLocked(n int, busyToo bool, minIdle time.Duration) int {
+	now := wp.clock.Now()
+	var candidates []*workerState
+	for _, state := range wp.workers {
+		if state.stopping {
+			continue
+		}
+		if state.busy && !busyToo {
+			continue
+		}
+		if !state.busy && now.Sub(state.since) < minIdle {
+			continue
+		}
+		candidates = append(candidates, state)
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		if candidates[i].busy != candidates[j].busy {
+			return !candidates[i].busy
+		}
+		return candidates[i].since.Before(candidates[j].since)
+	})
+
+	retired := 0
+	for _, state := range candidates {
+		if retired == n {
+			break
+		}
+		state.stopping = true
+		close(state.stop)
+		retired++
+	}
+	return retired
+}
+
+func (wp *WorkerPool) autoscale(ctx context.Context) {
+	defer wp.wg.Done()
+
+	ticker := wp.clock.NewTicker(wp.cfg.ScaleInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-wp.draining:
+			return
+		case <-ticker.C():
+			wp.scale()
+		}
+	}
+}
+
+// scale grows the pool when tasks are queued behind busy workers or start
+// later than ScaleUpWait, and retires a worker that has been idle for
+// ScaleDownIdle once the queue is empty.
+func (wp *WorkerPool) scale() {
+	wp.mu.Lock()
+	defer wp.mu.Unlock()
+
+	depth := len(wp.taskChan)
+	wait := wp.maxWait
+	wp.maxWait = 0
+
+	active, busy := 0, 0
+	for _, state := range wp.workers {
+		if state.stopping {
+			continue
+		}
+		active++
+		if state.busy {
+			busy++
+		}
+	}
+
+	backlog := depth > 0 && busy == active
+	late := wp.cfg.ScaleUpWait > 0 && wait > wp.cfg.ScaleUpWait
+	if (backlog || late) && active < wp.max {
+		add := min(max(depth, 1), wp.max-active)
+		log.Printf("Scaling workers up by %d to %d (queued %d, max wait %v)", add, active+add, depth, wait.Round(time.Millisecond))
+		wp.spawnLocked(add)
+		return
+	}
+
+	if depth == 0 && active > wp.min {
+		if wp.retireLocked(1, false, wp.cfg.ScaleDownIdle) > 0 {
+			log.Printf("Scaling workers down to %d", active-1)
+		}
+	}
+}
+
+// Resize sets the pool's bounds and starts or retires workers to fit them.
+// Workers above the new maximum that are busy exit after their current
+// task.
+func (wp *WorkerPool) Resize(minWorkers, maxWorkers int) error {
+	if minWorkers < 1 || maxWorkers < minWorkers {
+		return fmt.Errorf("invalid pool size: need 1 <= min (%d) <= max (%d)", minWorkers, maxWorkers)
+	}
+
+	wp.mu.Lock()
+	defer wp.mu.Unlock()
+
+	wp.min = minWorkers
+	wp.max = maxWorkers
+	active := wp.activeLocked()
+	switch {
+	case active < minWorkers:
+		wp.spawnLocked(minWorkers - active)
+	case active > maxWorkers:
+		wp.retireLocked(active-maxWorkers, true, 0)
+	}
+	log.Printf("Resized worker pool to min %d, max %d", minWorkers, maxWorkers)
+	return nil
+}
+
+// Status reports the pool's bounds and what each worker is doing.
+func (wp *WorkerPool) Status() entity.WorkerPoolStatus {
+	wp.mu.Lock()
+	defer wp.mu.Unlock()
+
+	status := entity.WorkerPoolStatus{
+		Min:        wp.min,
+		Max:        wp.max,
+		QueueDepth: len(wp.taskChan),
+		Workers:    []entity.WorkerStatus{},
+	}
+	for _, state := range wp.workers {
+		worker := entity.WorkerStatus{
+			ID:    state.id,
+			State: "idle",
+			Since: state.since,
+		}
+		if state.busy {
+			taskID := state.taskID
+			worker.State = "busy"
+			worker.TaskID = &taskID
+			worker.TaskName = state.taskName
+		}
+		if state.stopping {
+			worker.State = "stopping"
+		} else {
+			status.Size++
+			if state.busy {
+				status.Busy++
+			}
 		}
+		status.Workers = append(status.Workers, worker)
 	}
+	status.Idle = status.Size - status.Busy
+	sort.Slice(status.Workers, func(i, j int) bool {
+		return status.Workers[i].ID < status.Workers[j].ID
+	})
+	return status
 }
@@ -0,0 +1,103 @@
+package workers
+
+import (
+	"context"
+	"scheduler/clock"
+	"scheduler/database"
+	"testing"
+	"time"
+)
+
+func testWorkerPool(t *testing.T, clk clock.Clock, cfg Config) *WorkerPool {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	wp := NewWorkerPool(nil, clk, make(chan database.Task), cfg)
+	wp.Start(ctx)
+	t.Cleanup(func() {
+		cancel()
+		wp.wg.Wait()
+	})
+	return wp
+}
+
+func TestWorkerPoolResize(t *testing.T) {
+	wp := testWorkerPool(t, clock.New(), Config{MinWorkers: 2, MaxWorkers: 4})
+	if got := wp.Size(); got != 2 {
+		t.Fatalf("Size after Start = %d, want 2", got)
+	}
+
+	if err := wp.Resize(3, 5); err != nil {
+		t.Fatal(err)
+	}
+	if got := wp.Size(); got != 3 {
+		t.Errorf("Size after growing the minimum = %d, want 3", got)
+	}
+
+	if err := wp.Resize(1, 1); err != nil {
+		t.Fatal(err)
+	}
+	if got := wp.Size(); got != 1 {
+		t.Errorf("Size after shrinking the maximum = %d, want 1", got)
+	}
+	if status := wp.Status(); status.Min != 1 || status.Max != 1 || status.Idle != 1 {
+		t.Errorf("Status = %+v", status)
+	}
+
+	for _, bounds := range [][2]int{{0, 1}, {3, 2}} {
+		if err := wp.Resize(bounds[0], bounds[1]); err == nil {
+			t.Errorf("Resize(%d, %d) accepted", bounds[0], bounds[1])
+		}
+	}
+}
+
+func TestWorkerPoolScale(t *testing.T) {
+	clk := clock.NewFake(time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC))
+	wp := testWorkerPool(t, clk, Config{
+		MinWorkers:    1,
+		MaxWorkers:    3,
+		ScaleUpWait:   time.Second,
+		ScaleDownIdle: time.Minute,
+	})
+
+	wp.mu.Lock()
+	wp.maxWait = 2 * time.Second
+	wp.mu.Unlock()
+	wp.scale()
+	if got := wp.Size(); got != 2 {
+		t.Fatalf("Size after a late task = %d, want 2", got)
+	}
+
+	wp.scale()
+	if got := wp.Size(); got != 2 {
+		t.Errorf("Size with nothing queued and fresh workers = %d, want 2", got)
+	}
+
+	clk.Advance(2 * time.Minute)
+	wp.scale()
+	if got := wp.Size(); got != 1 {
+		t.Errorf("Size after the idle timeout = %d, want 1", got)
+	}
+	wp.scale()
+	if got := wp.Size(); got != 1 {
+		t.Errorf("Size went below the minimum: %d", got)
+	}
+}
+
+func TestWorkerPoolShutdown(t *testing.T) {
+	wp := testWorkerPool(t, clock.New(), Config{MinWorkers: 3, MaxWorkers: 3, ScaleInterval: time.Hour})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := wp.Shutdown(ctx); err != nil {
+		t.Fatalf("Shutdown = %v", err)
+	}
+	if got := wp.Size(); got != 0 {
+		t.Errorf("Size after Shutdown = %d, want 0", got)
+	}
+	if !wp.isDraining() {
+		t.Error("pool isn't draining after Shutdown")
+	}
+	if err := wp.Shutdown(ctx); err != nil {
+		t.Errorf("second Shutdown = %v", err)
+	}
+}